@@ -0,0 +1,201 @@
+package alert
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/94peter/export/csv"
+	"github.com/94peter/export/excel"
+	"github.com/94peter/export/pdf"
+	"github.com/94peter/export/pdf/style"
+)
+
+// Excursion 一次超出警告或警報門檻的事件
+type Excursion struct {
+	Sensor string
+	Start  time.Time
+	// 第一筆回到門檻內的讀值時間，資料中斷或結束時為最後一筆超出門檻的讀值時間
+	End time.Time
+	// 事件期間最偏離的值
+	Peak float64
+	// 事件期間達到的最高等級及其門檻
+	Severity pdf.Severity
+	Limit    float64
+	// 確認說明
+	Note string
+}
+
+func (e Excursion) Duration() time.Duration {
+	return e.End.Sub(e.Start)
+}
+
+func (e Excursion) high() bool {
+	return e.Severity == pdf.SeverityHighWarn || e.Severity == pdf.SeverityHighAlarm
+}
+
+// Excursions 依時間順序判斷超出門檻的事件，變化率不視為事件，遲滯沿用規則設定
+func (r Rule) Excursions(sensor string, readings []Reading) ([]Excursion, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	r.MaxRate = 0
+	e := &Evaluator{rule: r}
+	var (
+		result []Excursion
+		cur    *Excursion
+		last   time.Time
+	)
+	closeAt := func(t time.Time) {
+		if cur != nil {
+			cur.End = t
+			result = append(result, *cur)
+			cur = nil
+		}
+	}
+	for _, rd := range readings {
+		sev := e.Next(rd)
+		high := sev == pdf.SeverityHighWarn || sev == pdf.SeverityHighAlarm
+		low := sev == pdf.SeverityLowWarn || sev == pdf.SeverityLowAlarm
+		if cur != nil && (sev == pdf.SeverityMissing || (cur.high() && !high) || (!cur.high() && !low)) {
+			if sev == pdf.SeverityMissing {
+				closeAt(last)
+			} else {
+				closeAt(rd.Time)
+			}
+		}
+		if !high && !low {
+			continue
+		}
+		if cur == nil {
+			cur = &Excursion{Sensor: sensor, Start: rd.Time, Peak: rd.Value}
+		}
+		if (high && rd.Value > cur.Peak) || (low && rd.Value < cur.Peak) {
+			cur.Peak = rd.Value
+		}
+		if sev == pdf.SeverityHighAlarm || sev == pdf.SeverityLowAlarm || cur.Severity == pdf.SeverityNormal {
+			cur.Severity = sev
+			cur.Limit = r.limit(sev)
+		}
+		last = rd.Time
+	}
+	closeAt(last)
+	return result, nil
+}
+
+func (r Rule) limit(sev pdf.Severity) float64 {
+	l := map[pdf.Severity]*float64{
+		pdf.SeverityHighAlarm: r.HighAlarm,
+		pdf.SeverityHighWarn:  r.HighWarn,
+		pdf.SeverityLowWarn:   r.LowWarn,
+		pdf.SeverityLowAlarm:  r.LowAlarm,
+	}[sev]
+	if l == nil {
+		return math.NaN()
+	}
+	return *l
+}
+
+// ExcursionHeader 事件表格及匯出的欄位
+var ExcursionHeader = []string{"Sensor", "Start", "End", "Duration", "Peak", "Limit", "Note"}
+
+// Events 事件列表，可畫成 PDF 表格或匯出為 csv、excel
+type Events []Excursion
+
+const timeLayout = "2006-01-02 15:04"
+
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("%d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+func (es Events) row(i int) []string {
+	e := es[i]
+	return []string{
+		e.Sensor,
+		e.Start.Format(timeLayout),
+		e.End.Format(timeLayout),
+		formatDuration(e.Duration()),
+		fmt.Sprintf("%.1f", e.Peak),
+		fmt.Sprintf("%s %.1f", e.Severity, e.Limit),
+		e.Note,
+	}
+}
+
+// Draw 以 DrawEventTable 畫出事件表格，峰值及門檻欄依事件等級標示
+func (es Events) Draw(p pdf.PDF, ts style.EventTableStyle, pp ...pdf.AddPagePipe) error {
+	nti := pdf.GetSensorTableIter(ExcursionHeader)
+	for i, e := range es {
+		row := make([]pdf.SensorCell, len(ExcursionHeader))
+		for j, v := range es.row(i) {
+			row[j].Value = v
+		}
+		row[4].Severity, row[5].Severity = e.Severity, e.Severity
+		nti.AddRow(row)
+	}
+	return pdf.DrawEventTable(p, nti, ts, pp...)
+}
+
+// CSV 匯出用的資料來源
+func (es Events) CSV() csv.DS {
+	return &eventDS{events: es}
+}
+
+// Excel 匯出用的資料來源，只有一個工作表，sheet 為空時為 Excursions
+func (es Events) Excel(sheet string) excel.DS {
+	if sheet == "" {
+		sheet = "Excursions"
+	}
+	return &eventDS{events: es, sheet: sheet}
+}
+
+type eventDS struct {
+	events Events
+	sheet  string
+	index  int
+	// excel 已輸出工作表
+	paged bool
+}
+
+func (ds *eventDS) GetHeader() []string {
+	return ExcursionHeader
+}
+
+func (ds *eventDS) Next() []string {
+	if ds.index >= len(ds.events) {
+		return nil
+	}
+	ds.index++
+	return ds.events.row(ds.index - 1)
+}
+
+func (ds *eventDS) NextPage() (excel.Page, bool) {
+	if ds.paged {
+		return nil, false
+	}
+	ds.paged = true
+	return &eventPage{ds: ds, row: -1}, true
+}
+
+type eventPage struct {
+	ds  *eventDS
+	row int
+}
+
+func (p *eventPage) GetName() string {
+	return p.ds.sheet
+}
+
+// Next 第 0 列為標題
+func (p *eventPage) Next() (int, []string) {
+	if p.row < 0 {
+		p.row = 0
+		return 0, ExcursionHeader
+	}
+	d := p.ds.Next()
+	if d == nil {
+		return -1, nil
+	}
+	p.row++
+	return p.row, d
+}
@@ -0,0 +1,52 @@
+package alert
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/94peter/export/csv"
+	"github.com/94peter/export/pdf"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Excursions(t *testing.T) {
+	rule := Rule{HighAlarm: Float(8), HighWarn: Float(6), LowAlarm: Float(2)}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	values := []float64{5, 7, 9, 7, 5, 1, 1.5, math.NaN(), 3}
+	readings := make([]Reading, len(values))
+	for i, v := range values {
+		readings[i] = Reading{Time: start.Add(time.Duration(i) * 10 * time.Minute), Value: v}
+	}
+	excursions, err := rule.Excursions("A", readings)
+	assert.NoError(t, err)
+	events := Events(excursions)
+	assert.Len(t, events, 2)
+	assert.Equal(t, Excursion{
+		Sensor:   "A",
+		Start:    start.Add(10 * time.Minute),
+		End:      start.Add(40 * time.Minute),
+		Peak:     9,
+		Severity: pdf.SeverityHighAlarm,
+		Limit:    8,
+	}, events[0])
+	// 資料中斷時結束於最後一筆超出的讀值
+	assert.Equal(t, 10*time.Minute, events[1].Duration())
+	assert.Equal(t, 1.0, events[1].Peak)
+
+	events[0].Note = "door open"
+	var buf bytes.Buffer
+	assert.NoError(t, csv.NewCsv(events.CSV()).Write(&buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, "A,2024-01-01 00:10,2024-01-01 00:40,0:30,9.0,high alarm 8.0,door open", lines[1])
+
+	page, ok := events.Excel("").NextPage()
+	assert.True(t, ok)
+	assert.Equal(t, "Excursions", page.GetName())
+	n, row := page.Next()
+	assert.Equal(t, 0, n)
+	assert.Equal(t, ExcursionHeader, row)
+}
@@ -0,0 +1,201 @@
+// Package alert 依感測器門檻將讀值轉為報表用的 SensorCell
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/94peter/export/pdf"
+)
+
+// Reading 感測器讀值，Value 為 NaN 表示沒有讀值
+type Reading struct {
+	Time  time.Time
+	Value float64
+}
+
+// Float 方便設定門檻
+func Float(v float64) *float64 {
+	return &v
+}
+
+// Rule 單一感測器的警示規則，門檻為 nil 表示不檢查
+type Rule struct {
+	HighAlarm, HighWarn *float64
+	LowWarn, LowAlarm   *float64
+	// 解除警示時須回到門檻內側的幅度，避免在門檻附近反覆切換
+	Hysteresis float64
+	// 每 RatePer 時間內變化量超過 MaxRate 時警示，0 表示不檢查
+	MaxRate float64
+	RatePer time.Duration
+	// Cells 比對時間點時允許的誤差
+	Tolerance time.Duration
+}
+
+// ErrInvalidRule 規則設定不合法
+var ErrInvalidRule = errors.New("alert: invalid rule")
+
+// Validate 檢查規則，數值不可為負，設定 MaxRate 時 RatePer 須為正；
+// 警告門檻須在警報門檻內側，下限皆須低於上限
+func (r Rule) Validate() error {
+	if r.MaxRate < 0 || r.Hysteresis < 0 || r.Tolerance < 0 {
+		return fmt.Errorf("%w: must not be negative", ErrInvalidRule)
+	}
+	if r.MaxRate > 0 && r.RatePer <= 0 {
+		return fmt.Errorf("%w: RatePer must be positive when MaxRate is set", ErrInvalidRule)
+	}
+	if r.HighWarn != nil && r.HighAlarm != nil && *r.HighWarn > *r.HighAlarm {
+		return fmt.Errorf("%w: HighWarn %v above HighAlarm %v", ErrInvalidRule, *r.HighWarn, *r.HighAlarm)
+	}
+	if r.LowWarn != nil && r.LowAlarm != nil && *r.LowWarn < *r.LowAlarm {
+		return fmt.Errorf("%w: LowWarn %v below LowAlarm %v", ErrInvalidRule, *r.LowWarn, *r.LowAlarm)
+	}
+	for _, low := range []*float64{r.LowWarn, r.LowAlarm} {
+		for _, high := range []*float64{r.HighWarn, r.HighAlarm} {
+			if low != nil && high != nil && *low >= *high {
+				return fmt.Errorf("%w: low limit %v not below high limit %v", ErrInvalidRule, *low, *high)
+			}
+		}
+	}
+	return nil
+}
+
+// Rules 各感測器的規則，以感測器名稱為 key
+type Rules map[string]Rule
+
+// Evaluator 依序判斷讀值，保留前一筆狀態供遲滯及變化率使用
+type Evaluator struct {
+	rule Rule
+	// 前一筆的門檻狀態，不受變化率警示影響
+	state pdf.Severity
+	prev  *Reading
+}
+
+// NewEvaluator 建立判斷器，規則不合法時回傳 Validate 的錯誤
+func NewEvaluator(rule Rule) (*Evaluator, error) {
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	return &Evaluator{rule: rule}, nil
+}
+
+// Next 判斷下一筆讀值的警示等級，優先順序為 警報 > 變化率 > 警告
+func (e *Evaluator) Next(r Reading) pdf.Severity {
+	if math.IsNaN(r.Value) {
+		e.state, e.prev = pdf.SeverityMissing, nil
+		return pdf.SeverityMissing
+	}
+	level := e.level(r.Value)
+	sev := level
+	if sev != pdf.SeverityHighAlarm && sev != pdf.SeverityLowAlarm && e.overRate(r) {
+		sev = pdf.SeverityRate
+	}
+	e.state, e.prev = level, &r
+	return sev
+}
+
+// over 判斷是否超過門檻，已在該狀態時須回到門檻內 Hysteresis 才解除
+func (e *Evaluator) over(v float64, limit *float64, high bool, active ...pdf.Severity) bool {
+	if limit == nil {
+		return false
+	}
+	l := *limit
+	for _, s := range active {
+		if e.state == s {
+			if high {
+				l -= e.rule.Hysteresis
+			} else {
+				l += e.rule.Hysteresis
+			}
+			break
+		}
+	}
+	if high {
+		return v > l
+	}
+	return v < l
+}
+
+func (e *Evaluator) level(v float64) pdf.Severity {
+	r := e.rule
+	switch {
+	case e.over(v, r.HighAlarm, true, pdf.SeverityHighAlarm):
+		return pdf.SeverityHighAlarm
+	case e.over(v, r.LowAlarm, false, pdf.SeverityLowAlarm):
+		return pdf.SeverityLowAlarm
+	case e.over(v, r.HighWarn, true, pdf.SeverityHighWarn, pdf.SeverityHighAlarm):
+		return pdf.SeverityHighWarn
+	case e.over(v, r.LowWarn, false, pdf.SeverityLowWarn, pdf.SeverityLowAlarm):
+		return pdf.SeverityLowWarn
+	}
+	return pdf.SeverityNormal
+}
+
+func (e *Evaluator) overRate(r Reading) bool {
+	if e.rule.MaxRate == 0 || e.prev == nil {
+		return false
+	}
+	d := r.Time.Sub(e.prev.Time)
+	if d <= 0 {
+		return false
+	}
+	rate := math.Abs(r.Value-e.prev.Value) / (float64(d) / float64(e.rule.RatePer))
+	return rate > e.rule.MaxRate
+}
+
+// Evaluate 依時間順序判斷每筆讀值的警示等級
+func (r Rule) Evaluate(readings []Reading) ([]pdf.Severity, error) {
+	e, err := NewEvaluator(r)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]pdf.Severity, len(readings))
+	for i, rd := range readings {
+		result[i] = e.Next(rd)
+	}
+	return result, nil
+}
+
+// Cells 將讀值對應到 slots 的每個時間點，找不到讀值的時間點為 "-" 並標示為沒有讀值。
+// format 為 nil 時輸出小數一位。
+func (r Rule) Cells(readings []Reading, slots []time.Time, format func(float64) string) ([]pdf.SensorCell, error) {
+	if format == nil {
+		format = func(v float64) string { return strconv.FormatFloat(v, 'f', 1, 64) }
+	}
+	e, err := NewEvaluator(r)
+	if err != nil {
+		return nil, err
+	}
+	cells := make([]pdf.SensorCell, len(slots))
+	i := 0
+	for j, slot := range slots {
+		for i < len(readings) && readings[i].Time.Before(slot.Add(-r.Tolerance)) {
+			i++
+		}
+		rd := Reading{Time: slot, Value: math.NaN()}
+		if i < len(readings) && !readings[i].Time.After(slot.Add(r.Tolerance)) {
+			rd = readings[i]
+			i++
+		}
+		sev := e.Next(rd)
+		cells[j] = pdf.SensorCell{Value: "-", Severity: sev}
+		if sev != pdf.SeverityMissing {
+			cells[j].Value = format(rd.Value)
+		}
+		switch sev {
+		case pdf.SeverityHighAlarm:
+			cells[j].IsAlert = 1
+		case pdf.SeverityLowAlarm:
+			cells[j].IsAlert = -1
+		}
+	}
+	return cells, nil
+}
+
+// Cells 以 sensor 的規則轉換讀值，沒有規則時只標示沒有讀值
+func (rs Rules) Cells(sensor string, readings []Reading, slots []time.Time, format func(float64) string) ([]pdf.SensorCell, error) {
+	return rs[sensor].Cells(readings, slots, format)
+}
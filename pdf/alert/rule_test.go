@@ -0,0 +1,108 @@
+package alert
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/94peter/export/pdf"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Evaluate(t *testing.T) {
+	rule := Rule{
+		HighAlarm:  Float(8),
+		HighWarn:   Float(6),
+		LowWarn:    Float(3),
+		LowAlarm:   Float(2),
+		Hysteresis: 0.5,
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	values := []float64{5, 6.5, 8.5, 7.8, 7.4, 5.8, 5.4, 2.5, 1, math.NaN(), 4}
+	readings := make([]Reading, len(values))
+	for i, v := range values {
+		readings[i] = Reading{Time: start.Add(time.Duration(i) * time.Minute), Value: v}
+	}
+	sevs, err := rule.Evaluate(readings)
+	assert.NoError(t, err)
+	assert.Equal(t, []pdf.Severity{
+		pdf.SeverityNormal,
+		pdf.SeverityHighWarn,
+		pdf.SeverityHighAlarm,
+		// 遲滯：尚未低於 7.5
+		pdf.SeverityHighAlarm,
+		pdf.SeverityHighWarn,
+		pdf.SeverityHighWarn,
+		pdf.SeverityNormal,
+		pdf.SeverityLowWarn,
+		pdf.SeverityLowAlarm,
+		pdf.SeverityMissing,
+		pdf.SeverityNormal,
+	}, sevs)
+}
+
+func Test_Cells(t *testing.T) {
+	rule := Rule{HighAlarm: Float(10), MaxRate: 2, RatePer: time.Minute, Tolerance: 10 * time.Second}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	readings := []Reading{
+		{Time: start, Value: 5},
+		{Time: start.Add(time.Minute + 5*time.Second), Value: 9},
+		{Time: start.Add(3 * time.Minute), Value: 11},
+	}
+	slots := []time.Time{start, start.Add(time.Minute), start.Add(2 * time.Minute), start.Add(3 * time.Minute)}
+	cells, err := rule.Cells(readings, slots, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []pdf.SensorCell{
+		{Value: "5.0"},
+		{Value: "9.0", Severity: pdf.SeverityRate},
+		{Value: "-", Severity: pdf.SeverityMissing},
+		{Value: "11.0", IsAlert: 1, Severity: pdf.SeverityHighAlarm},
+	}, cells)
+}
+
+func Test_InvalidRule(t *testing.T) {
+	readings := []Reading{{Time: time.Now(), Value: 1}}
+	for _, rule := range []Rule{
+		{Hysteresis: -1},
+		{Tolerance: -time.Second},
+		{MaxRate: -1, RatePer: time.Minute},
+		{MaxRate: 2},
+		{HighWarn: Float(9), HighAlarm: Float(8)},
+		{LowWarn: Float(1), LowAlarm: Float(2)},
+		{LowWarn: Float(6), HighWarn: Float(6)},
+		{LowAlarm: Float(9), HighAlarm: Float(8)},
+		{LowWarn: Float(8.5), HighAlarm: Float(8)},
+	} {
+		assert.ErrorIs(t, rule.Validate(), ErrInvalidRule)
+		_, err := NewEvaluator(rule)
+		assert.ErrorIs(t, err, ErrInvalidRule)
+		_, err = rule.Evaluate(readings)
+		assert.ErrorIs(t, err, ErrInvalidRule)
+		_, err = rule.Cells(readings, []time.Time{readings[0].Time}, nil)
+		assert.ErrorIs(t, err, ErrInvalidRule)
+		_, err = rule.Excursions("A", readings)
+		assert.ErrorIs(t, err, ErrInvalidRule)
+	}
+	assert.NoError(t, Rule{MaxRate: 2, RatePer: time.Minute}.Validate())
+	assert.NoError(t, Rule{HighWarn: Float(8), HighAlarm: Float(8), LowWarn: Float(2), LowAlarm: Float(1)}.Validate())
+}
+
+func Test_RateKeepsHysteresis(t *testing.T) {
+	rule := Rule{HighWarn: Float(6), Hysteresis: 1, MaxRate: 2, RatePer: time.Minute}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	values := []float64{5, 6.5, 9, 5.5, 5.2}
+	readings := make([]Reading, len(values))
+	for i, v := range values {
+		readings[i] = Reading{Time: start.Add(time.Duration(i) * time.Minute), Value: v}
+	}
+	sevs, err := rule.Evaluate(readings)
+	assert.NoError(t, err)
+	assert.Equal(t, []pdf.Severity{
+		pdf.SeverityNormal,
+		pdf.SeverityHighWarn,
+		pdf.SeverityRate,
+		pdf.SeverityRate,
+		// 變化率警示後仍在警告的遲滯範圍內，尚未低於 5
+		pdf.SeverityHighWarn,
+	}, sevs)
+}
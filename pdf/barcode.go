@@ -0,0 +1,151 @@
+package pdf
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/datamatrix"
+	"github.com/boombuler/barcode/ean"
+	"github.com/boombuler/barcode/qr"
+)
+
+// QRLevel QR code 錯誤修正等級
+type QRLevel int
+
+const (
+	// 可修復 7%
+	QRLevelL QRLevel = iota
+	// 可修復 15%
+	QRLevelM
+	// 可修復 25%
+	QRLevelQ
+	// 可修復 30%
+	QRLevelH
+)
+
+func (l QRLevel) qrLevel() qr.ErrorCorrectionLevel {
+	switch l {
+	case QRLevelM:
+		return qr.M
+	case QRLevelQ:
+		return qr.Q
+	case QRLevelH:
+		return qr.H
+	}
+	return qr.L
+}
+
+// BarcodeDrawer 可繪製條碼，以向量矩形繪製於指定位置及尺寸
+type BarcodeDrawer interface {
+	QRCode(content string, level QRLevel, x, y, size float64) error
+	Code128(content string, x, y, w, h float64) error
+	EAN13(code string, x, y, w, h float64) error
+	DataMatrix(content string, x, y, size float64) error
+}
+
+// QRCode 繪製 QR code
+func QRCode(p PDF, content string, level QRLevel, x, y, size float64) error {
+	b, err := extension[BarcodeDrawer](p, "barcode")
+	if err != nil {
+		return err
+	}
+	return b.QRCode(content, level, x, y, size)
+}
+
+// Code128 繪製 Code128 條碼
+func Code128(p PDF, content string, x, y, w, h float64) error {
+	b, err := extension[BarcodeDrawer](p, "barcode")
+	if err != nil {
+		return err
+	}
+	return b.Code128(content, x, y, w, h)
+}
+
+// EAN13 繪製 EAN-13 條碼
+func EAN13(p PDF, code string, x, y, w, h float64) error {
+	b, err := extension[BarcodeDrawer](p, "barcode")
+	if err != nil {
+		return err
+	}
+	return b.EAN13(code, x, y, w, h)
+}
+
+// DataMatrix 繪製 DataMatrix
+func DataMatrix(p PDF, content string, x, y, size float64) error {
+	b, err := extension[BarcodeDrawer](p, "barcode")
+	if err != nil {
+		return err
+	}
+	return b.DataMatrix(content, x, y, size)
+}
+
+// QRCode 於 (x, y) 繪製邊長 size 的 QR code，不含四周留白
+func (p *pdfv2) QRCode(content string, level QRLevel, x, y, size float64) error {
+	bc, err := qr.Encode(content, level.qrLevel(), qr.Auto)
+	if err != nil {
+		return fmt.Errorf("qr code: %w", err)
+	}
+	p.drawBarcode(bc, x, y, size, size)
+	return nil
+}
+
+// Code128 於 (x, y) 繪製寬 w 高 h 的 Code128 條碼
+func (p *pdfv2) Code128(content string, x, y, w, h float64) error {
+	bc, err := code128.Encode(content)
+	if err != nil {
+		return fmt.Errorf("code128: %w", err)
+	}
+	p.drawBarcode(bc, x, y, w, h)
+	return nil
+}
+
+// EAN13 於 (x, y) 繪製寬 w 高 h 的 EAN-13 條碼，code 為 12 碼時自動補上檢查碼
+func (p *pdfv2) EAN13(code string, x, y, w, h float64) error {
+	if len(code) != 12 && len(code) != 13 {
+		return fmt.Errorf("ean13: invalid length %d", len(code))
+	}
+	bc, err := ean.Encode(code)
+	if err != nil {
+		return fmt.Errorf("ean13: %w", err)
+	}
+	p.drawBarcode(bc, x, y, w, h)
+	return nil
+}
+
+// DataMatrix 於 (x, y) 繪製邊長 size 的 DataMatrix (ECC200)
+func (p *pdfv2) DataMatrix(content string, x, y, size float64) error {
+	bc, err := datamatrix.Encode(content)
+	if err != nil {
+		return fmt.Errorf("datamatrix: %w", err)
+	}
+	p.drawBarcode(bc, x, y, size, size)
+	return nil
+}
+
+func isDark(c color.Color) bool {
+	g := color.GrayModel.Convert(c).(color.Gray)
+	return g.Y < 0x80
+}
+
+// drawBarcode 將條碼模組縮放至 w*h，同一列連續的深色模組合併為一個矩形
+func (p *pdfv2) drawBarcode(bc barcode.Barcode, x, y, w, h float64) {
+	b := bc.Bounds()
+	mw := w / float64(b.Dx())
+	mh := h / float64(b.Dy())
+	p.SetFillColor(0, 0, 0)
+	for row := 0; row < b.Dy(); row++ {
+		for col := 0; col < b.Dx(); {
+			if !isDark(bc.At(b.Min.X+col, b.Min.Y+row)) {
+				col++
+				continue
+			}
+			start := col
+			for col < b.Dx() && isDark(bc.At(b.Min.X+col, b.Min.Y+row)) {
+				col++
+			}
+			p.RectFromUpperLeftWithStyle(x+float64(start)*mw, y+float64(row)*mh, float64(col-start)*mw, mh, "F")
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Barcode(t *testing.T) {
+	p := NewPDFv2(nil, 20, 20, 20, 20)
+	p.AddDirectPage()
+	assert.NoError(t, QRCode(p, "https://example.com/record/42", QRLevelH, 20, 20, 80))
+	assert.NoError(t, Code128(p, "SHIP-000123", 120, 20, 160, 40))
+	assert.NoError(t, EAN13(p, "400638133393", 300, 20, 120, 50))
+	assert.NoError(t, DataMatrix(p, "LOT 2024-05", 440, 20, 60))
+
+	assert.Error(t, EAN13(p, "4006381333932", 20, 120, 120, 50))
+	assert.Error(t, EAN13(p, "12345", 20, 120, 120, 50))
+
+	assert.ErrorIs(t, QRCode(wrappedPDF{p}, "x", QRLevelL, 20, 220, 40), errors.ErrUnsupported)
+
+	var buf bytes.Buffer
+	assert.NoError(t, p.Write(&buf))
+}
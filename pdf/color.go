@@ -0,0 +1,110 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/94peter/export/pdf/style"
+	"github.com/signintech/gopdf"
+)
+
+func (p *pdfv2) checkCMYK(c style.Color) bool {
+	if !c.HasCMYK {
+		return false
+	}
+	if p.pdfa {
+		// PDF/A 的 OutputIntent 為 sRGB，改以 RGB 輸出
+		p.pdfaViolation("CMYK color")
+		return false
+	}
+	return true
+}
+
+func (p *pdfv2) setFillColor(c style.Color) {
+	if p.checkCMYK(c) {
+		p.SetFillColorCMYK(c.CMYK.C, c.CMYK.M, c.CMYK.Y, c.CMYK.K)
+		return
+	}
+	p.SetFillColor(c.R, c.G, c.B)
+}
+
+func (p *pdfv2) setStrokeColor(c style.Color) {
+	p.strokeColor = c
+	if p.checkCMYK(c) {
+		p.SetStrokeColorCMYK(c.CMYK.C, c.CMYK.M, c.CMYK.Y, c.CMYK.K)
+		return
+	}
+	p.SetStrokeColor(c.R, c.G, c.B)
+}
+
+func (p *pdfv2) setTextColor(c style.Color) {
+	if p.checkCMYK(c) {
+		p.SetTextColorCMYK(c.CMYK.C, c.CMYK.M, c.CMYK.Y, c.CMYK.K)
+		return
+	}
+	p.SetTextColor(c.R, c.G, c.B)
+}
+
+// withOpacity 以 c 的不透明度執行 draw，PDF/A 不允許半透明；無法設定透明度時不畫，錯誤於 Write 回傳
+func (p *pdfv2) withOpacity(c style.Color, draw func()) {
+	alpha := c.Opacity()
+	if alpha >= 1 {
+		draw()
+		return
+	}
+	if p.pdfa {
+		p.pdfaViolation("color opacity %.2f", alpha)
+		draw()
+		return
+	}
+	if err := p.SetTransparency(gopdf.Transparency{Alpha: alpha, BlendModeType: gopdf.NormalBlendMode}); err != nil {
+		p.errs = append(p.errs, fmt.Errorf("color opacity %.2f: %w", alpha, err))
+		return
+	}
+	defer p.ClearTransparency()
+	draw()
+}
+
+// rect 繪製矩形，rectType 含 F 時以 fill 填滿，含 D 時以目前的線條顏色畫框；
+// 填滿色半透明時框線分開繪製，維持不透明
+func (p *pdfv2) rect(x, y, w, h float64, fill style.Color, rectType string) {
+	fillRect := strings.Contains(rectType, "F")
+	drawRect := strings.Contains(rectType, "D")
+	if fillRect {
+		p.setFillColor(fill)
+	}
+	if !fillRect || fill.Opacity() >= 1 {
+		p.RectFromUpperLeftWithStyle(x, y, w, h, rectType)
+		return
+	}
+	p.withOpacity(fill, func() {
+		p.RectFromUpperLeftWithStyle(x, y, w, h, "F")
+	})
+	if drawRect {
+		p.RectFromUpperLeftWithStyle(x, y, w, h, "D")
+	}
+}
+
+// gradient 以細長矩形近似線性漸層
+func (p *pdfv2) gradient(x, y, w, h float64, g *style.Gradient) {
+	length := w
+	if g.Vertical {
+		length = h
+	}
+	steps := int(math.Min(math.Max(math.Ceil(length), 2), 256))
+	step := length / float64(steps)
+	for i := 0; i < steps; i++ {
+		c := g.At(float64(i) / float64(steps-1))
+		// 不透明時稍微重疊，避免檢視器出現細縫
+		overlap := 0.0
+		if c.Opacity() >= 1 && i < steps-1 {
+			overlap = step * 0.5
+		}
+		if g.Vertical {
+			p.rect(x, y+float64(i)*step, w, step+overlap, c, "F")
+		} else {
+			p.rect(x+float64(i)*step, y, step+overlap, h, c, "F")
+		}
+	}
+}
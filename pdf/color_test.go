@@ -0,0 +1,41 @@
+package pdf
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/94peter/export/pdf/style"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func testFontMap(t *testing.T) map[string]string {
+	fontFile := filepath.Join(t.TempDir(), "goregular.ttf")
+	assert.NoError(t, os.WriteFile(fontFile, goregular.TTF, 0o644))
+	return map[string]string{"tw-r": fontFile}
+}
+
+func Test_ColorOpacity(t *testing.T) {
+	p := NewPDFv2(testFontMap(t), 20, 20, 20, 20)
+	p.AddDirectPage()
+	p.LineWithColor(2, style.ColorMax.WithAlpha(0.5))
+	p.RectFillColor("Section", style.TextBlockStyle{
+		TextStyle: style.TextStyle{Font: "tw-r", FontSize: 12, Color: style.ColorBlack.WithAlpha(0.8)},
+		Gradient:  &style.Gradient{From: style.ColorWhite, To: style.NewCMYK(100, 0, 0, 0), Vertical: true},
+	}, 200, 40, style.AlignLeft, style.ValignMiddle)
+
+	var buf bytes.Buffer
+	assert.NoError(t, p.Write(&buf))
+	assert.Contains(t, buf.String(), "/CA 0.5")
+
+	p = NewPDFv2(nil, 20, 20, 20, 20, WithPDFA())
+	p.AddDirectPage()
+	p.LineWithColor(2, style.NewCMYK(0, 0, 0, 100))
+	p.LineWithColor(2, style.ColorMax.WithAlpha(0.5))
+	err := p.Write(&bytes.Buffer{})
+	assert.ErrorIs(t, err, ErrPDFA)
+	assert.ErrorContains(t, err, "CMYK")
+	assert.ErrorContains(t, err, "opacity")
+}
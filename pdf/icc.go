@@ -0,0 +1,121 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"sync"
+)
+
+var (
+	srgbOnce    sync.Once
+	srgbProfile []byte
+)
+
+// sRGBProfile 產生 sRGB IEC61966-2.1 的 ICC v2 色彩描述檔，供 PDF/A 的 OutputIntent 使用
+func sRGBProfile() []byte {
+	srgbOnce.Do(func() {
+		srgbProfile = buildSRGBProfile()
+	})
+	return srgbProfile
+}
+
+func s15Fixed16(v float64) uint32 {
+	return uint32(int32(math.Round(v * 65536)))
+}
+
+func xyzTag(x, y, z float64) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("XYZ ")
+	binary.Write(&buf, binary.BigEndian, [4]uint32{0, s15Fixed16(x), s15Fixed16(y), s15Fixed16(z)})
+	return buf.Bytes()
+}
+
+func textDescriptionTag(s string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("desc")
+	binary.Write(&buf, binary.BigEndian, [2]uint32{0, uint32(len(s) + 1)})
+	buf.WriteString(s)
+	buf.WriteByte(0)
+	// unicode 及 scriptcode 描述皆為空
+	buf.Write(make([]byte, 4+4+2+1+67))
+	return buf.Bytes()
+}
+
+func textTag(s string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("text")
+	buf.Write(make([]byte, 4))
+	buf.WriteString(s)
+	buf.WriteByte(0)
+	return buf.Bytes()
+}
+
+func srgbCurveTag() []byte {
+	const n = 1024
+	var buf bytes.Buffer
+	buf.WriteString("curv")
+	binary.Write(&buf, binary.BigEndian, [2]uint32{0, n})
+	for i := 0; i < n; i++ {
+		v := float64(i) / (n - 1)
+		if v <= 0.04045 {
+			v = v / 12.92
+		} else {
+			v = math.Pow((v+0.055)/1.055, 2.4)
+		}
+		binary.Write(&buf, binary.BigEndian, uint16(math.Round(v*65535)))
+	}
+	return buf.Bytes()
+}
+
+func buildSRGBProfile() []byte {
+	curve := srgbCurveTag()
+	tags := []struct {
+		sig  string
+		data []byte
+	}{
+		{"desc", textDescriptionTag("sRGB IEC61966-2.1")},
+		{"cprt", textTag("No copyright, use freely")},
+		{"wtpt", xyzTag(0.9505, 1.0, 1.0891)},
+		{"rXYZ", xyzTag(0.4361, 0.2225, 0.0139)},
+		{"gXYZ", xyzTag(0.3851, 0.7169, 0.0971)},
+		{"bXYZ", xyzTag(0.1431, 0.0606, 0.7141)},
+		{"rTRC", curve},
+		{"gTRC", curve},
+		{"bTRC", curve},
+	}
+
+	const headerLen = 128
+	tableLen := 4 + 12*len(tags)
+	var table, data bytes.Buffer
+	binary.Write(&table, binary.BigEndian, uint32(len(tags)))
+	// 內容相同的 tag 共用同一份資料
+	offsets := map[string]uint32{}
+	for _, t := range tags {
+		offset, ok := offsets[string(t.data)]
+		if !ok {
+			for data.Len()%4 != 0 {
+				data.WriteByte(0)
+			}
+			offset = uint32(headerLen + tableLen + data.Len())
+			offsets[string(t.data)] = offset
+			data.Write(t.data)
+		}
+		table.WriteString(t.sig)
+		binary.Write(&table, binary.BigEndian, [2]uint32{offset, uint32(len(t.data))})
+	}
+
+	size := headerLen + tableLen + data.Len()
+	var header bytes.Buffer
+	binary.Write(&header, binary.BigEndian, uint32(size))
+	header.Write(make([]byte, 4)) // CMM
+	binary.Write(&header, binary.BigEndian, uint32(0x02100000))
+	header.WriteString("mntrRGB XYZ ")
+	binary.Write(&header, binary.BigEndian, [6]uint16{2000, 1, 1, 0, 0, 0})
+	header.WriteString("acsp")
+	header.Write(make([]byte, 4+4+4+4+8+4)) // platform, flags, manufacturer, model, attributes, intent
+	binary.Write(&header, binary.BigEndian, [3]uint32{s15Fixed16(0.9642), s15Fixed16(1.0), s15Fixed16(0.8249)})
+	header.Write(make([]byte, headerLen-header.Len()))
+
+	return bytes.Join([][]byte{header.Bytes(), table.Bytes(), data.Bytes()}, nil)
+}
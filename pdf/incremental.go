@@ -0,0 +1,470 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto/rc4"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/signintech/gopdf"
+)
+
+var (
+	ErrMalformedPDF = errors.New("malformed pdf")
+
+	startxrefRegex = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`)
+	refRegex       = regexp.MustCompile(`^(\d+)\s+(\d+)\s+R`)
+	refRegexAll    = regexp.MustCompile(`(\d+)\s+(\d+)\s+R`)
+)
+
+// pdfUpdate 讀取已輸出的 PDF，以增量更新(incremental update)的方式附加或取代物件
+type pdfUpdate struct {
+	src     []byte
+	offsets map[int]int64
+	// 最新一節 xref 的位置與 trailer
+	xrefOffset int64
+	trailer    string
+
+	size       int
+	objs       map[int][]byte
+	trailerSet map[string]string
+
+	protection *gopdf.PDFProtection
+}
+
+func parseUpdate(src []byte) (*pdfUpdate, error) {
+	m := startxrefRegex.FindSubmatch(src)
+	if m == nil {
+		return nil, fmt.Errorf("%w: startxref not found", ErrMalformedPDF)
+	}
+	xrefOffset, _ := strconv.ParseInt(string(m[1]), 10, 64)
+	u := &pdfUpdate{
+		src:        src,
+		offsets:    map[int]int64{},
+		xrefOffset: xrefOffset,
+		objs:       map[int][]byte{},
+		trailerSet: map[string]string{},
+	}
+	offset := xrefOffset
+	for first := true; ; first = false {
+		trailer, err := u.readXref(offset)
+		if err != nil {
+			return nil, err
+		}
+		if first {
+			u.trailer = trailer
+			size, ok := dictGet(trailer, "Size")
+			if !ok {
+				return nil, fmt.Errorf("%w: trailer without /Size", ErrMalformedPDF)
+			}
+			u.size, _ = strconv.Atoi(size)
+		}
+		prev, ok := dictGet(trailer, "Prev")
+		if !ok {
+			break
+		}
+		offset, _ = strconv.ParseInt(prev, 10, 64)
+	}
+	return u, nil
+}
+
+// 讀取一節 xref，較新的物件位置不會被覆寫，回傳 trailer 字典
+func (u *pdfUpdate) readXref(offset int64) (string, error) {
+	if offset < 0 || offset >= int64(len(u.src)) || !bytes.HasPrefix(u.src[offset:], []byte("xref")) {
+		return "", fmt.Errorf("%w: xref not found at %d", ErrMalformedPDF, offset)
+	}
+	data := u.src[offset+4:]
+	end := bytes.Index(data, []byte("trailer"))
+	if end < 0 {
+		return "", fmt.Errorf("%w: trailer not found", ErrMalformedPDF)
+	}
+	fields := bytes.Fields(data[:end])
+	for i := 0; i+1 < len(fields); {
+		start, err1 := strconv.Atoi(string(fields[i]))
+		count, err2 := strconv.Atoi(string(fields[i+1]))
+		if err1 != nil || err2 != nil || i+2+count*3 > len(fields) {
+			return "", fmt.Errorf("%w: bad xref subsection", ErrMalformedPDF)
+		}
+		i += 2
+		for j := 0; j < count; j++ {
+			id := start + j
+			if string(fields[i+2]) == "n" {
+				if _, ok := u.offsets[id]; !ok {
+					off, _ := strconv.ParseInt(string(fields[i]), 10, 64)
+					u.offsets[id] = off
+				}
+			}
+			i += 3
+		}
+	}
+	trailer := data[end+len("trailer"):]
+	dictEnd := skipValue(trailer, skipSpace(trailer, 0))
+	if dictEnd < 0 {
+		return "", fmt.Errorf("%w: bad trailer", ErrMalformedPDF)
+	}
+	return string(bytes.TrimSpace(trailer[:dictEnd])), nil
+}
+
+// object 回傳物件內容(不含 obj/endobj)，已被取代的物件回傳新內容
+func (u *pdfUpdate) object(id int) ([]byte, error) {
+	if body, ok := u.objs[id]; ok {
+		return body, nil
+	}
+	off, ok := u.offsets[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: object %d not found", ErrMalformedPDF, id)
+	}
+	data := u.src[off:]
+	head := []byte(fmt.Sprintf("%d 0 obj", id))
+	if !bytes.HasPrefix(data, head) {
+		return nil, fmt.Errorf("%w: object %d not at %d", ErrMalformedPDF, id, off)
+	}
+	data = data[len(head):]
+	i := skipSpace(data, 0)
+	end := skipValue(data, i)
+	if end < 0 {
+		return nil, fmt.Errorf("%w: bad object %d", ErrMalformedPDF, id)
+	}
+	if j := skipSpace(data, end); bytes.HasPrefix(data[j:], []byte("stream")) {
+		k := bytes.Index(data[j:], []byte("endstream"))
+		if k < 0 {
+			return nil, fmt.Errorf("%w: bad stream %d", ErrMalformedPDF, id)
+		}
+		end = j + k + len("endstream")
+	}
+	return data[i:end], nil
+}
+
+// dict 取得物件字典
+func (u *pdfUpdate) dict(id int) (string, error) {
+	body, err := u.object(id)
+	if err != nil {
+		return "", err
+	}
+	end := skipValue(body, 0)
+	if !bytes.HasPrefix(body, []byte("<<")) || end < 0 {
+		return "", fmt.Errorf("%w: object %d is not a dictionary", ErrMalformedPDF, id)
+	}
+	return string(body[:end]), nil
+}
+
+// trailerRef 取得 trailer 中的物件參照，例如 Root
+func (u *pdfUpdate) trailerRef(key string) (int, bool) {
+	v, ok := u.trailerSet[key]
+	if !ok {
+		v, ok = dictGet(u.trailer, key)
+	}
+	if !ok {
+		return 0, false
+	}
+	return parseRef(v)
+}
+
+func (u *pdfUpdate) alloc() int {
+	id := u.size
+	u.size++
+	return id
+}
+
+func (u *pdfUpdate) set(id int, body []byte) {
+	u.objs[id] = body
+}
+
+func (u *pdfUpdate) setTrailer(key, value string) {
+	u.trailerSet[key] = value
+}
+
+// encrypt 文件有加密時以物件金鑰 RC4 加密字串或串流
+func (u *pdfUpdate) encrypt(id int, data []byte) []byte {
+	if u.protection == nil {
+		return data
+	}
+	cip, err := rc4.NewCipher(u.protection.Objectkey(id))
+	if err != nil {
+		panic(err)
+	}
+	dst := make([]byte, len(data))
+	cip.XORKeyStream(dst, data)
+	return dst
+}
+
+// textString 產生 UTF-16BE 十六進位字串
+func (u *pdfUpdate) textString(id int, s string) string {
+	data := []byte{0xfe, 0xff}
+	for _, r := range s {
+		if r >= 0x10000 {
+			r -= 0x10000
+			hi, lo := 0xd800+(r>>10), 0xdc00+(r&0x3ff)
+			data = append(data, byte(hi>>8), byte(hi), byte(lo>>8), byte(lo))
+			continue
+		}
+		data = append(data, byte(r>>8), byte(r))
+	}
+	return u.hexString(id, data)
+}
+
+// hexString 產生十六進位字串
+func (u *pdfUpdate) hexString(id int, data []byte) string {
+	return fmt.Sprintf("<%X>", u.encrypt(id, data))
+}
+
+// stream 產生串流物件內容
+func (u *pdfUpdate) stream(id int, dict string, data []byte) []byte {
+	data = u.encrypt(id, data)
+	dict = dictSet(dict, "Length", strconv.Itoa(len(data)))
+	var buf bytes.Buffer
+	buf.WriteString(dict)
+	buf.WriteString("\nstream\n")
+	buf.Write(data)
+	buf.WriteString("\nendstream")
+	return buf.Bytes()
+}
+
+func (u *pdfUpdate) newTrailer(prev bool) string {
+	trailer := "<<\n>>"
+	for _, key := range []string{"Root", "Info", "Encrypt", "ID"} {
+		if v, ok := dictGet(u.trailer, key); ok {
+			trailer = dictSet(trailer, key, v)
+		}
+	}
+	keys := make([]string, 0, len(u.trailerSet))
+	for k := range u.trailerSet {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		trailer = dictSet(trailer, k, u.trailerSet[k])
+	}
+	trailer = dictSet(trailer, "Size", strconv.Itoa(u.size))
+	if prev {
+		trailer = dictSet(trailer, "Prev", strconv.FormatInt(u.xrefOffset, 10))
+	}
+	return trailer
+}
+
+// write 輸出原文件並附加增量更新
+func (u *pdfUpdate) write(w io.Writer) error {
+	var buf bytes.Buffer
+	buf.Write(u.src)
+	if !bytes.HasSuffix(u.src, []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	ids := make([]int, 0, len(u.objs))
+	for id := range u.objs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	offsets := map[int]int64{}
+	for _, id := range ids {
+		offsets[id] = int64(buf.Len())
+		writeObj(&buf, id, u.objs[id])
+	}
+	xrefOffset := int64(buf.Len())
+	buf.WriteString("xref\n")
+	for i := 0; i < len(ids); {
+		j := i + 1
+		for j < len(ids) && ids[j] == ids[j-1]+1 {
+			j++
+		}
+		fmt.Fprintf(&buf, "%d %d\n", ids[i], j-i)
+		for _, id := range ids[i:j] {
+			fmt.Fprintf(&buf, "%010d 00000 n \n", offsets[id])
+		}
+		i = j
+	}
+	writeTrailer(&buf, u.newTrailer(true), xrefOffset)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writeObj(buf *bytes.Buffer, id int, body []byte) {
+	fmt.Fprintf(buf, "%d 0 obj\n", id)
+	buf.Write(body)
+	buf.WriteString("\nendobj\n")
+}
+
+func writeTrailer(buf *bytes.Buffer, trailer string, xrefOffset int64) {
+	buf.WriteString("trailer\n")
+	buf.WriteString(trailer)
+	fmt.Fprintf(buf, "\nstartxref\n%d\n%%%%EOF\n", xrefOffset)
+}
+
+func parseRef(v string) (int, bool) {
+	m := refRegex.FindStringSubmatch(v)
+	if m == nil {
+		return 0, false
+	}
+	id, _ := strconv.Atoi(m[1])
+	return id, true
+}
+
+func formatRef(id int) string {
+	return fmt.Sprintf("%d 0 R", id)
+}
+
+func isSpace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\n', '\f', 0:
+		return true
+	}
+	return false
+}
+
+func isDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return isSpace(c)
+}
+
+func skipSpace(data []byte, i int) int {
+	for i < len(data) {
+		if data[i] == '%' {
+			for i < len(data) && data[i] != '\n' && data[i] != '\r' {
+				i++
+			}
+			continue
+		}
+		if !isSpace(data[i]) {
+			break
+		}
+		i++
+	}
+	return i
+}
+
+// skipValue 回傳從 i 開始的一個 PDF 物件結束位置，參照(n g R)視為一個物件，失敗回傳 -1
+func skipValue(data []byte, i int) int {
+	if i >= len(data) {
+		return -1
+	}
+	switch c := data[i]; {
+	case bytes.HasPrefix(data[i:], []byte("<<")):
+		i += 2
+		for {
+			i = skipSpace(data, i)
+			if i >= len(data) {
+				return -1
+			}
+			if bytes.HasPrefix(data[i:], []byte(">>")) {
+				return i + 2
+			}
+			if i = skipValue(data, i); i < 0 {
+				return -1
+			}
+		}
+	case c == '[':
+		i++
+		for {
+			i = skipSpace(data, i)
+			if i >= len(data) {
+				return -1
+			}
+			if data[i] == ']' {
+				return i + 1
+			}
+			if i = skipValue(data, i); i < 0 {
+				return -1
+			}
+		}
+	case c == '<':
+		end := bytes.IndexByte(data[i:], '>')
+		if end < 0 {
+			return -1
+		}
+		return i + end + 1
+	case c == '(':
+		depth := 0
+		for ; i < len(data); i++ {
+			switch data[i] {
+			case '\\':
+				i++
+			case '(':
+				depth++
+			case ')':
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			}
+		}
+		return -1
+	case c == '/':
+		i++
+		for i < len(data) && !isDelimiter(data[i]) {
+			i++
+		}
+		return i
+	default:
+		start := i
+		for i < len(data) && !isDelimiter(data[i]) {
+			i++
+		}
+		if i == start {
+			return -1
+		}
+		if m := refRegex.FindIndex(data[start:]); m != nil && m[0] == 0 {
+			end := start + m[1]
+			if end == len(data) || isDelimiter(data[end]) {
+				return end
+			}
+		}
+		return i
+	}
+}
+
+// dictEntry 找出字典第一層的 key，回傳值的起訖位置
+func dictEntry(dict string, key string) (int, int, bool) {
+	data := []byte(dict)
+	i := skipSpace(data, 0)
+	if !bytes.HasPrefix(data[i:], []byte("<<")) {
+		return 0, 0, false
+	}
+	i += 2
+	for {
+		i = skipSpace(data, i)
+		if i >= len(data) || bytes.HasPrefix(data[i:], []byte(">>")) || data[i] != '/' {
+			return 0, 0, false
+		}
+		nameEnd := skipValue(data, i)
+		name := string(data[i+1 : nameEnd])
+		valStart := skipSpace(data, nameEnd)
+		valEnd := skipValue(data, valStart)
+		if valEnd < 0 {
+			return 0, 0, false
+		}
+		if name == key {
+			return valStart, valEnd, true
+		}
+		i = valEnd
+	}
+}
+
+func dictGet(dict string, key string) (string, bool) {
+	start, end, ok := dictEntry(dict, key)
+	if !ok {
+		return "", false
+	}
+	return dict[start:end], true
+}
+
+// dictSet 設定字典第一層的 key，不存在時加在字典結尾
+func dictSet(dict string, key, value string) string {
+	if start, end, ok := dictEntry(dict, key); ok {
+		return dict[:start] + value + dict[end:]
+	}
+	end := len(dict)
+	for end > 0 && isSpace(dict[end-1]) {
+		end--
+	}
+	end -= len(">>")
+	if end < 0 {
+		return dict
+	}
+	return dict[:end] + fmt.Sprintf("/%s %s\n", key, value) + dict[end:]
+}
@@ -0,0 +1,206 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/signintech/gopdf"
+)
+
+// Metadata 文件資訊，同時寫入 Info 字典及 XMP
+type Metadata struct {
+	Title    string
+	Author   string
+	Subject  string
+	Keywords []string
+	// 產生文件的應用程式
+	Creator  string
+	Producer string
+
+	// 零值時使用輸出當下時間
+	CreationDate time.Time
+	ModDate      time.Time
+}
+
+type Permission int
+
+// 允許的操作，未列出的操作皆禁止
+const (
+	PermissionPrint      Permission = gopdf.PermissionsPrint
+	PermissionModify     Permission = gopdf.PermissionsModify
+	PermissionCopy       Permission = gopdf.PermissionsCopy
+	PermissionAnnotForms Permission = gopdf.PermissionsAnnotForms
+)
+
+// Protection 密碼保護
+type Protection struct {
+	// 開啟文件的密碼，空白表示不需密碼即可開啟
+	UserPassword string
+	// 變更權限的密碼，空白時自動產生
+	OwnerPassword string
+	Permissions   Permission
+}
+
+// 產生擁有者密碼的亂數來源
+var randReader io.Reader = rand.Reader
+
+// WithProtection 以密碼保護文件並限制權限，無法產生擁有者密碼時由 Write 回傳錯誤
+func WithProtection(pt Protection) Option {
+	return func(p *pdfv2) {
+		if pt.OwnerPassword == "" {
+			b := make([]byte, 16)
+			if _, err := io.ReadFull(randReader, b); err != nil {
+				p.errs = append(p.errs, fmt.Errorf("owner password: %w", err))
+				return
+			}
+			pt.OwnerPassword = hex.EncodeToString(b)
+		}
+		p.protection = &pt
+		p.config.Protection = gopdf.PDFProtectionConfig{
+			UseProtection: true,
+			Permissions:   int(pt.Permissions),
+			UserPass:      []byte(pt.UserPassword),
+			OwnerPass:     []byte(pt.OwnerPassword),
+		}
+	}
+}
+
+// 與 gopdf 相同參數產生的加密資訊，用於加密增量更新的物件
+func (pt *Protection) pdfProtection() *gopdf.PDFProtection {
+	var prot gopdf.PDFProtection
+	prot.SetProtection(int(pt.Permissions), []byte(pt.UserPassword), []byte(pt.OwnerPassword))
+	return &prot
+}
+
+// MetadataSetter 可設定文件資訊
+type MetadataSetter interface {
+	SetMetadata(m Metadata)
+}
+
+// SetMetadata 設定文件資訊
+func SetMetadata(p PDF, m Metadata) error {
+	ms, err := extension[MetadataSetter](p, "metadata")
+	if err != nil {
+		return err
+	}
+	ms.SetMetadata(m)
+	return nil
+}
+
+// SetMetadata 設定文件資訊，於 Write 時寫入
+func (p *pdfv2) SetMetadata(m Metadata) {
+	p.metadata = &m
+}
+
+// writeMetadata 以增量更新寫入 Info 及 XMP。gopdf 的 SetInfo 將 Info 直接寫在 trailer，
+// 加密時字串不會加密，也沒有 Keywords 及 ModDate，因此不使用
+func (p *pdfv2) writeMetadata(u *pdfUpdate) error {
+	m := *p.metadata
+	if m.CreationDate.IsZero() {
+		m.CreationDate = time.Now()
+	}
+	if m.ModDate.IsZero() {
+		m.ModDate = m.CreationDate
+	}
+
+	infoID := u.alloc()
+	info := "<<\n>>"
+	for _, kv := range [][2]string{
+		{"Title", m.Title},
+		{"Author", m.Author},
+		{"Subject", m.Subject},
+		{"Keywords", strings.Join(m.Keywords, ", ")},
+		{"Creator", m.Creator},
+		{"Producer", m.Producer},
+	} {
+		if kv[1] != "" {
+			info = dictSet(info, kv[0], u.textString(infoID, kv[1]))
+		}
+	}
+	info = dictSet(info, "CreationDate", u.hexString(infoID, []byte(infoDate(m.CreationDate))))
+	info = dictSet(info, "ModDate", u.hexString(infoID, []byte(infoDate(m.ModDate))))
+	u.set(infoID, []byte(info))
+	u.setTrailer("Info", formatRef(infoID))
+
+	rootID, ok := u.trailerRef("Root")
+	if !ok {
+		return fmt.Errorf("%w: trailer without /Root", ErrMalformedPDF)
+	}
+	catalog, err := u.dict(rootID)
+	if err != nil {
+		return err
+	}
+	xmpID := u.alloc()
+	u.set(xmpID, u.stream(xmpID, "<<\n/Type /Metadata\n/Subtype /XML\n>>", m.xmp(p.pdfa)))
+	u.set(rootID, []byte(dictSet(catalog, "Metadata", formatRef(xmpID))))
+	return nil
+}
+
+func infoDate(t time.Time) string {
+	_, offset := t.Zone()
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+	return fmt.Sprintf("D:%s%s%02d'%02d'", t.Format("20060102150405"), sign, offset/3600, offset%3600/60)
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// xmp 產生 XMP 封包，pdfa 時加入 PDF/A-2b 識別
+func (m *Metadata) xmp(pdfa bool) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("<?xpacket begin=\"\xef\xbb\xbf\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	buf.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n")
+	buf.WriteString("<rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n")
+	buf.WriteString("<rdf:Description rdf:about=\"\"" +
+		" xmlns:dc=\"http://purl.org/dc/elements/1.1/\"" +
+		" xmlns:pdf=\"http://ns.adobe.com/pdf/1.3/\"" +
+		" xmlns:xmp=\"http://ns.adobe.com/xap/1.0/\"" +
+		" xmlns:pdfaid=\"http://www.aiim.org/pdfa/ns/id/\">\n")
+	buf.WriteString("<dc:format>application/pdf</dc:format>\n")
+	if pdfa {
+		buf.WriteString("<pdfaid:part>2</pdfaid:part>\n<pdfaid:conformance>B</pdfaid:conformance>\n")
+	}
+	if m.Title != "" {
+		fmt.Fprintf(&buf, "<dc:title><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:title>\n", xmlEscape(m.Title))
+	}
+	if m.Author != "" {
+		fmt.Fprintf(&buf, "<dc:creator><rdf:Seq><rdf:li>%s</rdf:li></rdf:Seq></dc:creator>\n", xmlEscape(m.Author))
+	}
+	if m.Subject != "" {
+		fmt.Fprintf(&buf, "<dc:description><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:description>\n", xmlEscape(m.Subject))
+	}
+	if len(m.Keywords) > 0 {
+		keywords := strings.Join(m.Keywords, ", ")
+		fmt.Fprintf(&buf, "<pdf:Keywords>%s</pdf:Keywords>\n", xmlEscape(keywords))
+		buf.WriteString("<dc:subject><rdf:Bag>")
+		for _, k := range m.Keywords {
+			fmt.Fprintf(&buf, "<rdf:li>%s</rdf:li>", xmlEscape(k))
+		}
+		buf.WriteString("</rdf:Bag></dc:subject>\n")
+	}
+	if m.Producer != "" {
+		fmt.Fprintf(&buf, "<pdf:Producer>%s</pdf:Producer>\n", xmlEscape(m.Producer))
+	}
+	if m.Creator != "" {
+		fmt.Fprintf(&buf, "<xmp:CreatorTool>%s</xmp:CreatorTool>\n", xmlEscape(m.Creator))
+	}
+	fmt.Fprintf(&buf, "<xmp:CreateDate>%s</xmp:CreateDate>\n", m.CreationDate.Format(time.RFC3339))
+	fmt.Fprintf(&buf, "<xmp:ModifyDate>%s</xmp:ModifyDate>\n", m.ModDate.Format(time.RFC3339))
+	fmt.Fprintf(&buf, "<xmp:MetadataDate>%s</xmp:MetadataDate>\n", m.ModDate.Format(time.RFC3339))
+	buf.WriteString("</rdf:Description>\n</rdf:RDF>\n</x:xmpmeta>\n")
+	buf.WriteString("<?xpacket end=\"w\"?>")
+	return buf.Bytes()
+}
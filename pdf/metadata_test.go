@@ -0,0 +1,236 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rc4"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// literalString 解開 PDF 字面字串 (...) 的跳脫字元
+func literalString(s string) []byte {
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "("), ")")
+	var out []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 == len(s) {
+			out = append(out, c)
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case 't':
+			out = append(out, '\t')
+		case 'b':
+			out = append(out, '\b')
+		case 'f':
+			out = append(out, '\f')
+		default:
+			if s[i] >= '0' && s[i] <= '7' {
+				j := i
+				for j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7' {
+					j++
+				}
+				v, _ := strconv.ParseUint(s[i:j], 8, 8)
+				out = append(out, byte(v))
+				i = j - 1
+				continue
+			}
+			out = append(out, s[i])
+		}
+	}
+	return out
+}
+
+func rc4XOR(key, data []byte) []byte {
+	cip, _ := rc4.NewCipher(key)
+	out := make([]byte, len(data))
+	cip.XORKeyStream(out, data)
+	return out
+}
+
+// standardKey 依 PDF 標準安全處理 R2 由使用者密碼算出文件金鑰
+func standardKey(password string, o []byte, p int32) []byte {
+	padded := append([]byte(password), protectionPaddingTest...)[:32]
+	h := md5.New()
+	h.Write(padded)
+	h.Write(o)
+	binary.Write(h, binary.LittleEndian, p)
+	return h.Sum(nil)[:5]
+}
+
+var protectionPaddingTest = []byte{
+	0x28, 0xBF, 0x4E, 0x5E, 0x4E, 0x75, 0x8A, 0x41, 0x64, 0x00, 0x4E, 0x56, 0xFF, 0xFA, 0x01, 0x08,
+	0x2E, 0x2E, 0x00, 0xB6, 0xD0, 0x68, 0x3E, 0x80, 0x2F, 0x0C, 0xA9, 0xFE, 0x64, 0x53, 0x69, 0x7A,
+}
+
+func objectKey(key []byte, id int) []byte {
+	sum := md5.Sum(append(append([]byte{}, key...), byte(id), byte(id>>8), byte(id>>16), 0, 0))
+	return sum[:10]
+}
+
+// textValue 解出 Info 的 UTF-16BE 十六進位字串，key 不為 nil 時先解密
+func textValue(t *testing.T, v string, key []byte, id int) string {
+	data, err := hex.DecodeString(strings.Trim(v, "<>"))
+	assert.NoError(t, err)
+	if key != nil {
+		data = rc4XOR(objectKey(key, id), data)
+	}
+	if !bytes.HasPrefix(data, []byte{0xfe, 0xff}) {
+		return string(data)
+	}
+	units := make([]uint16, 0, len(data)/2)
+	for i := 2; i+1 < len(data); i += 2 {
+		units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// readMetadata 讀回 Info 字典及 XMP，key 不為 nil 時解密
+func readMetadata(t *testing.T, out []byte, key []byte) (map[string]string, string) {
+	u, err := parseUpdate(out)
+	assert.NoError(t, err)
+	infoID, ok := u.trailerRef("Info")
+	assert.True(t, ok)
+	info, err := u.dict(infoID)
+	assert.NoError(t, err)
+	values := map[string]string{}
+	for _, k := range []string{"Title", "Author", "Subject", "Keywords", "Creator", "Producer", "CreationDate", "ModDate"} {
+		if v, ok := dictGet(info, k); ok {
+			values[k] = textValue(t, v, key, infoID)
+		}
+	}
+
+	rootID, _ := u.trailerRef("Root")
+	catalog, err := u.dict(rootID)
+	assert.NoError(t, err)
+	ref, ok := dictGet(catalog, "Metadata")
+	assert.True(t, ok)
+	xmpID, _ := parseRef(ref)
+	body, err := u.object(xmpID)
+	assert.NoError(t, err)
+	data := body[bytes.Index(body, []byte("stream\n"))+len("stream\n") : bytes.LastIndex(body, []byte("\nendstream"))]
+	if key != nil {
+		data = rc4XOR(objectKey(key, xmpID), data)
+	}
+	return values, string(data)
+}
+
+func Test_Metadata(t *testing.T) {
+	m := Metadata{
+		Title:        "冷鏈日報 <A&B>",
+		Author:       "QA",
+		Subject:      "Daily",
+		Keywords:     []string{"cold", "chain"},
+		Creator:      "export",
+		Producer:     "gopdf",
+		CreationDate: time.Date(2024, 5, 1, 8, 30, 0, 0, time.FixedZone("CST", 8*3600)),
+	}
+	check := func(info map[string]string, xmp string) {
+		assert.Equal(t, m.Title, info["Title"])
+		assert.Equal(t, "QA", info["Author"])
+		assert.Equal(t, "cold, chain", info["Keywords"])
+		assert.Equal(t, "D:20240501083000+08'00'", info["CreationDate"])
+		assert.Equal(t, info["CreationDate"], info["ModDate"])
+
+		// XMP 須為合法 XML 並與 Info 一致
+		dec := xml.NewDecoder(strings.NewReader(xmp))
+		var title []string
+		inTitle := false
+		for {
+			tok, err := dec.Token()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if !assert.NoError(t, err) {
+				break
+			}
+			switch tok := tok.(type) {
+			case xml.StartElement:
+				inTitle = inTitle || tok.Name.Local == "title"
+			case xml.EndElement:
+				inTitle = inTitle && tok.Name.Local != "title"
+			case xml.CharData:
+				if inTitle {
+					title = append(title, string(tok))
+				}
+			}
+		}
+		assert.Equal(t, []string{m.Title}, title)
+		assert.Contains(t, xmp, "<xmp:CreateDate>2024-05-01T08:30:00+08:00</xmp:CreateDate>")
+		assert.Contains(t, xmp, "<rdf:li>chain</rdf:li>")
+	}
+
+	p := NewPDFv2(nil, 20, 20, 20, 20)
+	assert.NoError(t, SetMetadata(p, m))
+	p.AddDirectPage()
+	var buf bytes.Buffer
+	assert.NoError(t, p.Write(&buf))
+	assert.NotContains(t, buf.String(), "/Encrypt")
+	check(readMetadata(t, buf.Bytes(), nil))
+
+	p = NewPDFv2(nil, 20, 20, 20, 20, WithProtection(Protection{
+		UserPassword:  "user",
+		OwnerPassword: "owner",
+		Permissions:   PermissionPrint | PermissionCopy,
+	}))
+	assert.NoError(t, SetMetadata(p, m))
+	p.AddDirectPage()
+	buf.Reset()
+	assert.NoError(t, p.Write(&buf))
+	out := buf.Bytes()
+	assert.NotContains(t, string(out), "冷鏈")
+
+	u, err := parseUpdate(out)
+	assert.NoError(t, err)
+	encID, ok := u.trailerRef("Encrypt")
+	assert.True(t, ok)
+	enc, err := u.dict(encID)
+	assert.NoError(t, err)
+	filter, _ := dictGet(enc, "Filter")
+	assert.Equal(t, "/Standard", filter)
+	ov, _ := dictGet(enc, "O")
+	uv, _ := dictGet(enc, "U")
+	pv, _ := dictGet(enc, "P")
+	o, uValue := literalString(ov), literalString(uv)
+	perm, err := strconv.ParseInt(pv, 10, 32)
+	assert.NoError(t, err)
+	// 第 3 位元列印、第 5 位元複製，修改及註解未允許
+	assert.NotZero(t, perm&4)
+	assert.NotZero(t, perm&16)
+	assert.Zero(t, perm&8)
+	assert.Zero(t, perm&32)
+
+	key := standardKey("user", o, int32(perm))
+	assert.Equal(t, uValue, rc4XOR(key, protectionPaddingTest))
+	assert.NotEqual(t, uValue, rc4XOR(standardKey("wrong", o, int32(perm)), protectionPaddingTest))
+	ownerKey := md5.Sum(append([]byte("owner"), protectionPaddingTest...)[:32])
+	assert.Equal(t, append([]byte("user"), protectionPaddingTest...)[:32], rc4XOR(ownerKey[:5], o))
+	check(readMetadata(t, out, key))
+
+	randReader = errReader{}
+	defer func() { randReader = rand.Reader }()
+	p = NewPDFv2(nil, 20, 20, 20, 20, WithProtection(Protection{UserPassword: "user"}))
+	p.AddDirectPage()
+	assert.ErrorContains(t, p.Write(&bytes.Buffer{}), "owner password")
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, errors.New("no entropy") }
@@ -0,0 +1,154 @@
+package mychart
+
+import (
+	"math"
+	"time"
+
+	"github.com/94peter/export/pdf/style"
+	"github.com/wcharczuk/go-chart"
+	"github.com/wcharczuk/go-chart/drawing"
+)
+
+// Marker 垂直標記線，例如開門、除霜、確認警報
+type Marker struct {
+	// 與 TimestampList 相同的 unix 秒數
+	Time  int64
+	Label string
+	// 未設定時為黑色
+	Color style.Color
+}
+
+// TimeRange 時間區間底色，例如超出門檻的事件、維護時段
+type TimeRange struct {
+	Start, End int64
+	Label      string
+	// 未設定時為半透明 ColorHeatAlert
+	Color style.Color
+}
+
+// ValueBand 兩個數值之間的水平底色，Min 或 Max 可為 ±Inf 表示延伸到圖表邊界
+type ValueBand struct {
+	Min, Max float64
+	Label    string
+	// 未設定時為半透明 ColorCoolAlert
+	Color style.Color
+	// 依第二 Y 軸的範圍
+	Secondary bool
+}
+
+// layer 以 Series 的方式畫在資料線下方，與資料線使用相同的座標換算，不顯示於圖例
+type layer struct {
+	yAxis  chart.YAxisType
+	render func(r chart.Renderer, cb chart.Box, x func(ts int64) int, y func(v float64) int, defaults chart.Style)
+}
+
+func (l layer) GetName() string           { return "" }
+func (l layer) GetYAxis() chart.YAxisType { return l.yAxis }
+func (l layer) GetStyle() chart.Style     { return chart.Style{Show: true} }
+func (l layer) Validate() error           { return nil }
+
+func (l layer) Render(r chart.Renderer, cb chart.Box, xrange, yrange chart.Range, defaults chart.Style) {
+	x := func(ts int64) int {
+		return cb.Left + xrange.Translate(float64(time.Unix(ts, 0).UnixNano()))
+	}
+	y := func(v float64) int {
+		switch {
+		case math.IsInf(v, 1) || v > yrange.GetMax():
+			return cb.Top
+		case math.IsInf(v, -1) || v < yrange.GetMin():
+			return cb.Bottom
+		}
+		return cb.Bottom - yrange.Translate(v)
+	}
+	l.render(r, cb, x, y, defaults)
+}
+
+func (tlc *TimeLineChart) hasAnnotations() bool {
+	return len(tlc.Markers) > 0 || len(tlc.Ranges) > 0 || len(tlc.Bands) > 0
+}
+
+// includeBands 將有限的帶狀範圍納入 Y 軸
+func (tlc *timeLineState) includeBands() {
+	for _, b := range tlc.Bands {
+		for _, v := range []float64{b.Min, b.Max} {
+			if math.IsInf(v, 0) || math.IsNaN(v) {
+				continue
+			}
+			if b.Secondary {
+				tlc.min2, tlc.max2 = math.Min(tlc.min2, v), math.Max(tlc.max2, v)
+				continue
+			}
+			tlc.min, tlc.max = math.Min(tlc.min, v), math.Max(tlc.max, v)
+		}
+	}
+}
+
+func annotationColor(c, fallback style.Color) drawing.Color {
+	if c == (style.Color{}) {
+		c = fallback
+	}
+	return drawing.Color{R: c.R, G: c.G, B: c.B, A: uint8(c.Opacity() * 255)}
+}
+
+func annotationLabel(r chart.Renderer, defaults chart.Style, text string, left, top int, color drawing.Color) {
+	if text == "" {
+		return
+	}
+	chart.Draw.Text(r, text, left+3, top+12, chart.Style{Font: defaults.Font, FontSize: 9, FontColor: color})
+}
+
+// annotations 依序畫出水平帶、時間區間及垂直標記，第二 Y 軸的水平帶另成一層
+func (tlc *TimeLineChart) annotations() []chart.Series {
+	bands := func(secondary bool) func(chart.Renderer, chart.Box, func(int64) int, func(float64) int, chart.Style) {
+		return func(r chart.Renderer, cb chart.Box, _ func(int64) int, y func(float64) int, defaults chart.Style) {
+			for _, b := range tlc.Bands {
+				if b.Secondary != secondary {
+					continue
+				}
+				top, bottom := y(math.Max(b.Min, b.Max)), y(math.Min(b.Min, b.Max))
+				if top >= bottom {
+					continue
+				}
+				fill := annotationColor(b.Color, style.ColorCoolAlert.WithAlpha(0.35))
+				chart.Draw.Box(r, chart.Box{Top: top, Left: cb.Left, Right: cb.Right, Bottom: bottom}, chart.Style{
+					FillColor:   fill,
+					StrokeColor: fill,
+				})
+				annotationLabel(r, defaults, b.Label, cb.Left, top, drawing.ColorBlack)
+			}
+		}
+	}
+	result := []chart.Series{layer{render: bands(false)}}
+	if tlc.hasSecondary() {
+		result = append(result, layer{yAxis: chart.YAxisSecondary, render: bands(true)})
+	}
+	return append(result, layer{render: func(r chart.Renderer, cb chart.Box, x func(int64) int, _ func(float64) int, defaults chart.Style) {
+		for _, tr := range tlc.Ranges {
+			// 區間超出時間軸時只畫落在繪圖區內的部分
+			left, right := max(x(tr.Start), cb.Left), min(x(tr.End), cb.Right)
+			if left >= right {
+				continue
+			}
+			fill := annotationColor(tr.Color, style.ColorHeatAlert.WithAlpha(0.35))
+			chart.Draw.Box(r, chart.Box{Top: cb.Top, Left: left, Right: right, Bottom: cb.Bottom}, chart.Style{
+				FillColor:   fill,
+				StrokeColor: fill,
+			})
+			annotationLabel(r, defaults, tr.Label, left, cb.Top, drawing.ColorBlack)
+		}
+		for i, m := range tlc.Markers {
+			left := x(m.Time)
+			if left < cb.Left || left > cb.Right {
+				continue
+			}
+			color := annotationColor(m.Color, style.ColorBlack)
+			chart.Style{StrokeColor: color, StrokeWidth: 1, StrokeDashArray: []float64{4, 2}}.WriteDrawingOptionsToRenderer(r)
+			r.MoveTo(left, cb.Top)
+			r.LineTo(left, cb.Bottom)
+			r.Stroke()
+			r.ResetStyle()
+			// 錯開標籤高度避免相鄰標記重疊
+			annotationLabel(r, defaults, m.Label, left, cb.Top+(i%3)*12, color)
+		}
+	}})
+}
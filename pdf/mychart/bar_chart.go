@@ -0,0 +1,227 @@
+package mychart
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+
+	"github.com/94peter/export/pdf"
+	"github.com/94peter/export/pdf/style"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/text"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// BarSeries 一組長條或折線，Values 依序對應 Categories
+type BarSeries struct {
+	Name   string
+	Values []float64
+	Color  style.Color
+}
+
+// BarChart 長條圖，多組資料時並排或堆疊，設定 Histogram 時改畫直方圖
+type BarChart struct {
+	Title      string
+	XAxisName  string
+	YAxisName  string
+	Categories []string
+	Series     []BarSeries
+	// 疊加在長條上的折線
+	Lines   []BarSeries
+	Stacked bool
+	// 在長條上方顯示數值，堆疊時顯示總和
+	ShowValues bool
+	DP         uint // 小數位數，0 為一位
+
+	// 直方圖的原始資料，設定時忽略 Categories、Series 及 Lines
+	Histogram []float64
+	// 直方圖的分組數，0 時依 Sturges 規則計算
+	Bins  int
+	Color style.Color
+
+	// 預設 1000*600
+	ChartOptions
+}
+
+func toColor(c, fallback style.Color) color.Color {
+	if c == (style.Color{}) {
+		c = fallback
+	}
+	return color.NRGBA{R: c.R, G: c.G, B: c.B, A: uint8(c.Opacity() * 255)}
+}
+
+// Draw 依 Format 輸出，fontfile 為空字串時使用內建字型
+func (bc *BarChart) Draw(fontfile string, w io.Writer) error {
+	width, height := bc.size(1000, 600)
+	if err := bc.render(w, fontfile, width, height, bc.paint); err != nil {
+		return fmt.Errorf("bar chart: %w", err)
+	}
+	return nil
+}
+
+// DrawPDF 畫入 PDF 頁面的矩形
+func (bc *BarChart) DrawPDF(p pdf.PDF, fontfile string, x, y, w, h float64) error {
+	if err := bc.renderPDF(p, fontfile, x, y, w, h, bc.paint); err != nil {
+		return fmt.Errorf("bar chart: %w", err)
+	}
+	return nil
+}
+
+func (bc *BarChart) paint(c draw.Canvas, pf *plotFont) error {
+	p := plot.New()
+	pf.apply(p)
+	p.Title.Text = bc.Title
+	p.X.Label.Text = bc.XAxisName
+	p.Y.Label.Text = bc.YAxisName
+	p.Legend.Top = true
+
+	var err error
+	if len(bc.Histogram) > 0 {
+		err = bc.histogram(p)
+	} else {
+		err = bc.bars(p, pf, c.Size().X)
+	}
+	if err != nil {
+		return err
+	}
+	p.Draw(c)
+	return nil
+}
+
+// sturges Sturges 規則的分組數
+func sturges(n int) int {
+	return int(math.Ceil(math.Log2(float64(n)))) + 1
+}
+
+func (bc *BarChart) histogram(p *plot.Plot) error {
+	bins := bc.Bins
+	if bins <= 0 {
+		bins = sturges(len(bc.Histogram))
+	}
+	h, err := plotter.NewHist(plotter.Values(bc.Histogram), bins)
+	if err != nil {
+		return err
+	}
+	h.FillColor = toColor(bc.Color, style.Palette(1)[0])
+	h.LineStyle.Color = color.White
+	p.Add(h)
+	return nil
+}
+
+func (bc *BarChart) bars(p *plot.Plot, pf *plotFont, width vg.Length) error {
+	n := len(bc.Categories)
+	if n == 0 || len(bc.Series) == 0 {
+		return errors.New("no data")
+	}
+	for _, s := range bc.Series {
+		if len(s.Values) != n {
+			return fmt.Errorf("series %s has %d values, want %d", s.Name, len(s.Values), n)
+		}
+	}
+	palette := style.Palette(len(bc.Series) + len(bc.Lines))
+	groups := len(bc.Series)
+	if bc.Stacked {
+		groups = 1
+	}
+	// 繪圖區約為寬度的八成五，長條佔每個分類的七成
+	barW := width * 0.85 * 0.7 / vg.Length(n*groups)
+
+	var prev *plotter.BarChart
+	totals := make([]float64, n)
+	for i, s := range bc.Series {
+		bar, err := plotter.NewBarChart(plotter.Values(s.Values), barW)
+		if err != nil {
+			return err
+		}
+		bar.LineStyle.Width = 0
+		bar.Color = toColor(s.Color, palette[i])
+		if bc.Stacked {
+			if prev != nil {
+				bar.StackOn(prev)
+			}
+			prev = bar
+		} else {
+			bar.Offset = barW * (vg.Length(i) - vg.Length(groups-1)/2)
+		}
+		p.Add(bar)
+		p.Legend.Add(s.Name, bar)
+		if !bc.Stacked && bc.ShowValues {
+			if err = bc.valueLabels(p, pf, s.Values, bar.Offset); err != nil {
+				return err
+			}
+		}
+		for j, v := range s.Values {
+			totals[j] += v
+		}
+	}
+	if bc.Stacked && bc.ShowValues {
+		if err := bc.valueLabels(p, pf, totals, 0); err != nil {
+			return err
+		}
+	}
+	for i, l := range bc.Lines {
+		if len(l.Values) != n {
+			return fmt.Errorf("line %s has %d values, want %d", l.Name, len(l.Values), n)
+		}
+		xys := make(plotter.XYs, n)
+		for j, v := range l.Values {
+			xys[j] = plotter.XY{X: float64(j), Y: v}
+		}
+		line, points, err := plotter.NewLinePoints(xys)
+		if err != nil {
+			return err
+		}
+		c := toColor(l.Color, palette[len(bc.Series)+i])
+		line.Color, points.Color = c, c
+		p.Add(line, points)
+		p.Legend.Add(l.Name, line, points)
+	}
+	p.NominalX(bc.Categories...)
+	// 預留兩側長條及上方數值、圖例的空間
+	p.X.Min, p.X.Max = -0.5, float64(n)-0.5
+	tops := [][]float64{totals}
+	if !bc.Stacked {
+		tops = tops[:0]
+		for _, s := range bc.Series {
+			tops = append(tops, s.Values)
+		}
+	}
+	for _, l := range bc.Lines {
+		tops = append(tops, l.Values)
+	}
+	top := 0.0
+	for _, values := range tops {
+		for _, v := range values {
+			top = math.Max(top, v)
+		}
+	}
+	p.Y.Max = math.Max(p.Y.Max, top*1.4)
+	return nil
+}
+
+// valueLabels 在各分類的 values 高度上方顯示數值
+func (bc *BarChart) valueLabels(p *plot.Plot, pf *plotFont, values []float64, offset vg.Length) error {
+	dp := decimals(bc.DP)
+	xyl := plotter.XYLabels{XYs: make(plotter.XYs, len(values)), Labels: make([]string, len(values))}
+	for i := range values {
+		xyl.XYs[i] = plotter.XY{X: float64(i), Y: values[i]}
+		xyl.Labels[i] = fmt.Sprintf("%.*f", dp, values[i])
+	}
+	labels, err := plotter.NewLabels(xyl)
+	if err != nil {
+		return err
+	}
+	for i := range labels.TextStyle {
+		pf.style(&labels.TextStyle[i], 8)
+		labels.TextStyle[i].XAlign = text.XCenter
+		labels.TextStyle[i].YAlign = text.YBottom
+	}
+	labels.Offset = vg.Point{X: offset, Y: 2}
+	p.Add(labels)
+	return nil
+}
@@ -0,0 +1,58 @@
+package mychart
+
+import (
+	"bytes"
+	"image/png"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/94peter/export/pdf"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BarChart(t *testing.T) {
+	bc := &BarChart{
+		Title:      "Alarms",
+		YAxisName:  "Count",
+		Categories: []string{"Mon", "Tue", "Wed"},
+		Series: []BarSeries{
+			{Name: "High", Values: []float64{3, 1, 4}},
+			{Name: "Low", Values: []float64{1, 5, 9}},
+		},
+		Lines:        []BarSeries{{Name: "Target", Values: []float64{2, 2, 2}}},
+		ShowValues:   true,
+		ChartOptions: ChartOptions{Width: 400, Height: 300},
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, bc.Draw("", &buf))
+	img, err := png.Decode(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, img.Bounds().Dx())
+
+	// DP 為 0 時與其他圖表相同顯示一位小數
+	bc.Format = FormatSVG
+	buf.Reset()
+	assert.NoError(t, bc.Draw("", &buf))
+	assert.Contains(t, buf.String(), ">9.0<")
+
+	bc.Stacked = true
+	p := pdf.NewPDFv2(nil, 20, 20, 20, 20)
+	p.AddDirectPage()
+	assert.NoError(t, bc.DrawPDF(p, "", 20, 20, 400, 240))
+	buf.Reset()
+	assert.NoError(t, p.Write(&buf))
+	assert.Equal(t, 1, strings.Count(buf.String(), "/Subtype /Image"))
+
+	bc.Series[1].Values = []float64{1}
+	assert.Error(t, bc.Draw("", &buf))
+
+	values := make([]float64, 200)
+	for i := range values {
+		values[i] = rand.NormFloat64()
+	}
+	assert.Equal(t, 9, sturges(len(values)))
+	hist := &BarChart{Histogram: values, ChartOptions: ChartOptions{Width: 400, Height: 300}}
+	buf.Reset()
+	assert.NoError(t, hist.Draw("", &buf))
+}
@@ -0,0 +1,365 @@
+package mychart
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/94peter/export/pdf"
+	"github.com/94peter/export/pdf/style"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// DistributionKind 分佈圖的畫法
+type DistributionKind uint8
+
+const (
+	// 箱形圖，箱為四分位數，鬚為 1.5 倍四分位距內的最遠讀值，之外的讀值畫點
+	DistributionBox DistributionKind = iota
+	// 小提琴圖，以核密度估計畫出左右對稱的分佈，中間標示四分位數及中位數
+	DistributionViolin
+	// 橫向直方圖，各感測器使用相同的分組
+	DistributionHistogram
+)
+
+// DistributionChart 每個感測器一組的讀值分佈，資料與 TimeLineChart 相同，上下限畫在最上層
+type DistributionChart struct {
+	Title string
+	Kind  DistributionKind
+	// 只取這些時間的讀值，空時使用 Data 的全部讀值
+	TimestampList []int64
+	// 每條線一組，設定 Secondary 的線不列入
+	TimeData     []TimeLine
+	UpperValue   float64
+	LowerValue   float64
+	NoUpperLower bool
+	YAxisName    string
+	// 使用 LowerLimit 及 UpperLimit
+	Labels ChartLabels
+	// 直方圖的分組數，0 時依 Sturges 規則計算
+	Bins int
+
+	// 預設 1000*600
+	ChartOptions
+}
+
+// NewDistributionChart 以趨勢圖的資料、上下限及文字建立分佈圖
+func NewDistributionChart(tlc *TimeLineChart, kind DistributionKind) *DistributionChart {
+	return &DistributionChart{
+		Kind:          kind,
+		TimestampList: tlc.TimestampList,
+		TimeData:      tlc.TimeData,
+		UpperValue:    tlc.UpperValue,
+		LowerValue:    tlc.LowerValue,
+		NoUpperLower:  tlc.NoUpperLower,
+		YAxisName:     tlc.YAxisName,
+		Labels:        tlc.Labels,
+		ChartOptions: ChartOptions{
+			Width:  tlc.Width,
+			Height: tlc.Height,
+			Format: tlc.Format,
+			Scale:  tlc.Scale,
+			Fonts:  tlc.Fonts,
+		},
+	}
+}
+
+// sensor 一個感測器排序後的讀值
+type sensor struct {
+	name   string
+	color  color.Color
+	values []float64
+}
+
+// sensors 依 TimeData 順序取出各線的讀值，略過 NaN 及沒有讀值的時間
+func (dc *DistributionChart) sensors() ([]sensor, error) {
+	var result []sensor
+	palette := style.Palette(len(dc.TimeData))
+	total := 0
+	for i := range dc.TimeData {
+		line := &dc.TimeData[i]
+		if line.Secondary {
+			continue
+		}
+		var values []float64
+		add := func(v float64, ok bool) {
+			if ok && !math.IsNaN(v) {
+				values = append(values, v)
+			}
+		}
+		if len(dc.TimestampList) > 0 {
+			for _, ts := range dc.TimestampList {
+				v, ok := line.Data[ts]
+				add(v, ok)
+			}
+		} else {
+			for _, v := range line.Data {
+				add(v, true)
+			}
+		}
+		sort.Float64s(values)
+		total += len(values)
+		result = append(result, sensor{name: line.Name, color: toColor(line.Color, palette[i]), values: values})
+	}
+	if total == 0 {
+		return nil, ErrNoData
+	}
+	return result, nil
+}
+
+// Draw 依 Format 輸出，fontfile 為空字串時使用內建字型
+func (dc *DistributionChart) Draw(fontfile string, w io.Writer) error {
+	width, height := dc.size(1000, 600)
+	if err := dc.render(w, fontfile, width, height, dc.paint); err != nil {
+		return fmt.Errorf("distribution chart: %w", err)
+	}
+	return nil
+}
+
+// DrawPDF 畫入 PDF 頁面的矩形
+func (dc *DistributionChart) DrawPDF(p pdf.PDF, fontfile string, x, y, w, h float64) error {
+	if err := dc.renderPDF(p, fontfile, x, y, w, h, dc.paint); err != nil {
+		return fmt.Errorf("distribution chart: %w", err)
+	}
+	return nil
+}
+
+func (dc *DistributionChart) paint(c draw.Canvas, pf *plotFont) error {
+	sensors, err := dc.sensors()
+	if err != nil {
+		return err
+	}
+	p := plot.New()
+	pf.apply(p)
+	p.Title.Text = dc.Title
+	p.Y.Label.Text = dc.YAxisName
+	p.Legend.Top = true
+
+	n := len(sensors)
+	// 每個感測器佔一單位寬，圖形佔其中六成
+	boxW := c.Size().X * 0.85 * 0.6 / vg.Length(n)
+	edges := dc.edges(sensors)
+	names := make([]string, n)
+	for i, s := range sensors {
+		names[i] = s.name
+		if len(s.values) == 0 {
+			continue
+		}
+		box, err := plotter.NewBoxPlot(boxW, float64(i), plotter.Values(s.values))
+		if err != nil {
+			return fmt.Errorf("%s: %w", s.name, err)
+		}
+		switch dc.Kind {
+		case DistributionViolin:
+			p.Add(&violin{box: box, values: s.values, color: s.color})
+		case DistributionHistogram:
+			p.Add(&sideHist{box: box, counts: histogram(s.values, edges), edges: edges, color: s.color})
+		default:
+			box.FillColor = s.color
+			box.GlyphStyle.Color = s.color
+			p.Add(box)
+		}
+	}
+	p.NominalX(names...)
+	p.X.Min, p.X.Max = -0.5, float64(n)-0.5
+	if n > 12 {
+		p.X.Tick.Label.Rotation = math.Pi / 4
+		p.X.Tick.Label.XAlign = draw.XRight
+		p.X.Tick.Label.YAlign = draw.YCenter
+	}
+	if !dc.NoUpperLower {
+		l := dc.Labels.withDefaults()
+		dc.limit(p, dc.UpperValue, style.ColorHeatAlert, l.UpperLimit)
+		dc.limit(p, dc.LowerValue, style.ColorCoolAlert, l.LowerLimit)
+		p.Y.Min = math.Min(p.Y.Min, dc.LowerValue)
+		p.Y.Max = math.Max(p.Y.Max, dc.UpperValue)
+	}
+	// 預留上方圖例的空間
+	p.Y.Max += (p.Y.Max - p.Y.Min) * 0.1
+	p.Draw(c)
+	return nil
+}
+
+// limit 橫跨所有感測器的上下限虛線，最後加入所以畫在最上層
+func (dc *DistributionChart) limit(p *plot.Plot, v float64, c style.Color, name string) {
+	line := &plotter.Line{XYs: plotter.XYs{{X: p.X.Min, Y: v}, {X: p.X.Max, Y: v}}}
+	line.LineStyle = draw.LineStyle{Color: toColor(c.WithAlpha(1), c), Width: vg.Points(1.5), Dashes: []vg.Length{vg.Points(5), vg.Points(3)}}
+	p.Add(line)
+	p.Legend.Add(name, line)
+}
+
+// edges 直方圖共用的分組邊界，只在 DistributionHistogram 時計算
+func (dc *DistributionChart) edges(sensors []sensor) []float64 {
+	if dc.Kind != DistributionHistogram {
+		return nil
+	}
+	lo, hi, most := math.Inf(1), math.Inf(-1), 0
+	for _, s := range sensors {
+		if len(s.values) == 0 {
+			continue
+		}
+		lo, hi = math.Min(lo, s.values[0]), math.Max(hi, s.values[len(s.values)-1])
+		if len(s.values) > most {
+			most = len(s.values)
+		}
+	}
+	bins := dc.Bins
+	if bins <= 0 {
+		bins = sturges(most)
+	}
+	if lo == hi {
+		lo, hi = lo-0.5, hi+0.5
+	}
+	edges := make([]float64, bins+1)
+	for i := range edges {
+		edges[i] = lo + (hi-lo)*float64(i)/float64(bins)
+	}
+	return edges
+}
+
+// histogram 已排序讀值在各分組的筆數，最後一組包含上界
+func histogram(values, edges []float64) []int {
+	counts := make([]int, len(edges)-1)
+	for _, v := range values {
+		i := sort.SearchFloat64s(edges, v)
+		if i == len(edges) || edges[i] != v || i == len(edges)-1 {
+			i--
+		}
+		if i >= 0 && i < len(counts) {
+			counts[i]++
+		}
+	}
+	return counts
+}
+
+// silverman Silverman 規則的核密度頻寬
+func silverman(sorted []float64) float64 {
+	n := float64(len(sorted))
+	var mean, sd float64
+	for _, v := range sorted {
+		mean += v
+	}
+	mean /= n
+	for _, v := range sorted {
+		sd += (v - mean) * (v - mean)
+	}
+	sd = math.Sqrt(sd / n)
+	iqr := sorted[int(0.75*(n-1))] - sorted[int(0.25*(n-1))]
+	spread := sd
+	if iqr > 0 {
+		spread = math.Min(sd, iqr/1.34)
+	}
+	return 0.9 * spread * math.Pow(n, -0.2)
+}
+
+// density 以高斯核估計 ys 各點的密度，回傳值以最大值正規化為 0-1。
+// 讀值先分成 256 組以組中點及筆數計算，資料量大時不會逐筆計算
+func density(sorted, ys []float64) []float64 {
+	bw := silverman(sorted)
+	d := make([]float64, len(ys))
+	if bw == 0 {
+		return d
+	}
+	lo, hi := sorted[0], sorted[len(sorted)-1]
+	const bins = 256
+	edges := make([]float64, bins+1)
+	for i := range edges {
+		edges[i] = lo + (hi-lo)*float64(i)/bins
+	}
+	counts := histogram(sorted, edges)
+	most := 0.0
+	for i, y := range ys {
+		for j, n := range counts {
+			if n == 0 {
+				continue
+			}
+			z := (y - (edges[j]+edges[j+1])/2) / bw
+			d[i] += float64(n) * math.Exp(-z*z/2)
+		}
+		most = math.Max(most, d[i])
+	}
+	for i := range d {
+		d[i] /= most
+	}
+	return d
+}
+
+// violin 以 BoxPlot 的位置、寬度及統計值畫小提琴圖
+type violin struct {
+	box    *plotter.BoxPlot
+	values []float64
+	color  color.Color
+}
+
+func (v *violin) DataRange() (xmin, xmax, ymin, ymax float64) {
+	return v.box.DataRange()
+}
+
+func (v *violin) Plot(c draw.Canvas, plt *plot.Plot) {
+	trX, trY := plt.Transforms(&c)
+	x := trX(v.box.Location)
+	half := v.box.Width / 2
+	lo, hi := v.values[0], v.values[len(v.values)-1]
+	const steps = 64
+	ys := make([]float64, steps+1)
+	for i := range ys {
+		ys[i] = lo + (hi-lo)*float64(i)/steps
+	}
+	d := density(v.values, ys)
+	pts := make([]vg.Point, 0, 2*len(ys))
+	for i, y := range ys {
+		pts = append(pts, vg.Point{X: x + half*vg.Length(d[i]), Y: trY(y)})
+	}
+	for i := len(ys) - 1; i >= 0; i-- {
+		pts = append(pts, vg.Point{X: x - half*vg.Length(d[i]), Y: trY(ys[i])})
+	}
+	if lo == hi {
+		// 所有讀值相同時畫一條橫線
+		c.StrokeLine2(draw.LineStyle{Color: v.color, Width: vg.Points(2)}, x-half, trY(lo), x+half, trY(lo))
+	} else {
+		c.FillPolygon(v.color, c.ClipPolygonXY(pts))
+	}
+	c.StrokeLine2(draw.LineStyle{Color: color.Black, Width: vg.Points(3)}, x, trY(v.box.Quartile1), x, trY(v.box.Quartile3))
+	c.StrokeLine2(draw.LineStyle{Color: color.Black, Width: vg.Points(0.5)}, x, trY(lo), x, trY(hi))
+	c.DrawGlyph(draw.GlyphStyle{Color: color.White, Radius: vg.Points(2), Shape: draw.CircleGlyph{}}, vg.Point{X: x, Y: trY(v.box.Median)})
+}
+
+// sideHist 由 BoxPlot 位置的左側向右畫的橫向直方圖，長度以該感測器最多的一組為滿寬
+type sideHist struct {
+	box    *plotter.BoxPlot
+	counts []int
+	edges  []float64
+	color  color.Color
+}
+
+func (h *sideHist) DataRange() (xmin, xmax, ymin, ymax float64) {
+	loc := h.box.Location
+	return loc, loc, h.edges[0], h.edges[len(h.edges)-1]
+}
+
+func (h *sideHist) Plot(c draw.Canvas, plt *plot.Plot) {
+	trX, trY := plt.Transforms(&c)
+	x := trX(h.box.Location) - h.box.Width/2
+	most := 0
+	for _, n := range h.counts {
+		if n > most {
+			most = n
+		}
+	}
+	for i, n := range h.counts {
+		if n == 0 {
+			continue
+		}
+		w := h.box.Width * vg.Length(n) / vg.Length(most)
+		y0, y1 := trY(h.edges[i]), trY(h.edges[i+1])
+		c.FillPolygon(h.color, c.ClipPolygonXY([]vg.Point{{X: x, Y: y0}, {X: x + w, Y: y0}, {X: x + w, Y: y1}, {X: x, Y: y1}}))
+	}
+	med := trY(h.box.Median)
+	c.StrokeLine2(draw.LineStyle{Color: color.Black, Width: vg.Points(1.5)}, x, med, x+h.box.Width, med)
+}
@@ -0,0 +1,67 @@
+package mychart
+
+import (
+	"bytes"
+	"image/png"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/94peter/export/pdf"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DistributionChart(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tlc := &TimeLineChart{UpperValue: 8, LowerValue: 2, YAxisName: "°C"}
+	for i := 0; i < 1000; i++ {
+		tlc.TimestampList = append(tlc.TimestampList, int64(i*60))
+	}
+	for _, name := range []string{"A", "B", "C"} {
+		line := TimeLine{Name: name, Data: map[int64]float64{}}
+		for j, ts := range tlc.TimestampList {
+			if j%10 != 0 {
+				line.Data[ts] = 5 + r.NormFloat64()
+			}
+		}
+		tlc.TimeData = append(tlc.TimeData, line)
+	}
+	tlc.TimeData[1].Data[0] = math.NaN()
+	tlc.TimeData = append(tlc.TimeData, TimeLine{Name: "RH", Secondary: true, Data: map[int64]float64{0: 60}})
+
+	dc := NewDistributionChart(tlc, DistributionBox)
+	sensors, err := dc.sensors()
+	assert.NoError(t, err)
+	assert.Len(t, sensors, 3)
+	assert.Len(t, sensors[0].values, 900)
+	assert.True(t, sort.Float64sAreSorted(sensors[0].values))
+
+	assert.Equal(t, []int{1, 0, 3}, histogram([]float64{0, 2, 3, 3}, []float64{0, 1, 2, 3}))
+
+	for _, kind := range []DistributionKind{DistributionBox, DistributionViolin, DistributionHistogram} {
+		dc.Kind = kind
+		dc.Width, dc.Height = 400, 300
+		var buf bytes.Buffer
+		assert.NoError(t, dc.Draw("", &buf))
+		img, err := png.Decode(&buf)
+		assert.NoError(t, err)
+		assert.Equal(t, 400, img.Bounds().Dx())
+	}
+	var buf bytes.Buffer
+	dc.Format = FormatSVG
+	assert.NoError(t, dc.Draw("", &buf))
+	assert.True(t, strings.Contains(buf.String(), "<svg"))
+
+	p := pdf.NewPDFv2(nil, 20, 20, 20, 20)
+	p.AddDirectPage()
+	assert.NoError(t, dc.DrawPDF(p, "", 20, 20, 400, 300))
+
+	assert.ErrorIs(t, (&DistributionChart{}).Draw("", &buf), ErrNoData)
+
+	// 沿用趨勢圖的輸出設定
+	fonts := NewFontCache()
+	dc = NewDistributionChart(&TimeLineChart{Width: 400, Format: FormatPDF, Scale: 2, Fonts: fonts}, DistributionBox)
+	assert.Equal(t, ChartOptions{Width: 400, Format: FormatPDF, Scale: 2, Fonts: fonts}, dc.ChartOptions)
+}
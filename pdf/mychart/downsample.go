@@ -0,0 +1,223 @@
+package mychart
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/wcharczuk/go-chart"
+)
+
+// Downsample 資料點過多時的降取樣方式，超出上下限的讀值一定保留
+type Downsample uint8
+
+const (
+	DownsampleNone Downsample = iota
+	// Largest-Triangle-Three-Buckets，保留線的形狀
+	DownsampleLTTB
+	// 每個區間保留最大及最小值
+	DownsampleMinMax
+	// 固定間隔平均，區間內有超出上下限的讀值時以最偏離的讀值代替平均
+	DownsampleAverage
+)
+
+func (d Downsample) String() string {
+	switch d {
+	case DownsampleLTTB:
+		return "LTTB"
+	case DownsampleMinMax:
+		return "min/max"
+	case DownsampleAverage:
+		return "average"
+	}
+	return "none"
+}
+
+// maxPoints 每條線最多的點數，未設定時為圖寬
+func (tlc *timeLineState) maxPoints() int {
+	if tlc.MaxPoints > 0 {
+		return tlc.MaxPoints
+	}
+	return tlc.width
+}
+
+// excess 超出上下限的程度，未超出為 0
+func (tlc *TimeLineChart) excess(v float64) float64 {
+	if tlc.NoUpperLower {
+		return 0
+	}
+	return math.Max(0, math.Max(v-tlc.UpperValue, tlc.LowerValue-v))
+}
+
+// peak 區間 [from, to) 內最偏離上下限的讀值位置，沒有時回傳 -1
+func peak(ys []float64, from, to int, excess func(float64) float64) int {
+	index, most := -1, 0.0
+	for i := from; i < to; i++ {
+		if e := excess(ys[i]); e > most {
+			index, most = i, e
+		}
+	}
+	return index
+}
+
+// budget 一段 n 筆資料可使用的點數，依占 TimestampList 的比例分配，至少為 3
+func (tlc *timeLineState) budget(n int) int {
+	b := int(math.Ceil(float64(n) * float64(tlc.maxPoints()) / float64(len(tlc.TimestampList))))
+	if b < 3 {
+		return 3
+	}
+	return b
+}
+
+// reduce 依 Downsample 減少資料點，第二 Y 軸的線不套用上下限
+func (tlc *timeLineState) reduce(xs []time.Time, ys []float64, secondary bool) ([]time.Time, []float64) {
+	if tlc.Downsample == DownsampleNone {
+		return xs, ys
+	}
+	excess := tlc.excess
+	if secondary {
+		excess = func(float64) float64 { return 0 }
+	}
+	budget := tlc.budget(len(xs))
+	tlc.sampledFrom += len(xs)
+	switch tlc.Downsample {
+	case DownsampleLTTB:
+		if len(xs) > budget {
+			xs, ys = pick(xs, ys, lttb(xs, ys, budget, excess))
+		}
+	case DownsampleMinMax:
+		if len(xs) > budget {
+			xs, ys = pick(xs, ys, minMax(ys, budget))
+		}
+	case DownsampleAverage:
+		interval := tlc.AverageInterval
+		if interval <= 0 && len(xs) > budget {
+			interval = xs[len(xs)-1].Sub(xs[0]) / time.Duration(budget)
+		}
+		if interval > 0 {
+			xs, ys = average(xs, ys, interval, excess)
+		}
+	}
+	tlc.sampledTo += len(xs)
+	return xs, ys
+}
+
+func pick(xs []time.Time, ys []float64, index []int) ([]time.Time, []float64) {
+	px := make([]time.Time, len(index))
+	py := make([]float64, len(index))
+	for i, j := range index {
+		px[i], py[i] = xs[j], ys[j]
+	}
+	return px, py
+}
+
+// lttb 保留頭尾，其餘分成 n-2 個區間，每區間選出與前一點及下一區間平均點面積最大的點
+func lttb(xs []time.Time, ys []float64, n int, excess func(float64) float64) []int {
+	x := func(i int) float64 { return float64(xs[i].Unix()) }
+	size := float64(len(xs)-2) / float64(n-2)
+	result := make([]int, 0, n)
+	result = append(result, 0)
+	prev := 0
+	for b := 0; b < n-2; b++ {
+		from, to := int(float64(b)*size)+1, int(float64(b+1)*size)+1
+		if p := peak(ys, from, to, excess); p >= 0 {
+			result = append(result, p)
+			prev = p
+			continue
+		}
+		// 下一區間的平均點
+		nfrom, nto := to, int(float64(b+2)*size)+1
+		if nto > len(xs) {
+			nto = len(xs)
+		}
+		var ax, ay float64
+		for i := nfrom; i < nto; i++ {
+			ax += x(i)
+			ay += ys[i]
+		}
+		ax /= float64(nto - nfrom)
+		ay /= float64(nto - nfrom)
+
+		best, area := from, -1.0
+		for i := from; i < to; i++ {
+			a := math.Abs((x(prev)-ax)*(ys[i]-ys[prev]) - (x(prev)-x(i))*(ay-ys[prev]))
+			if a > area {
+				best, area = i, a
+			}
+		}
+		result = append(result, best)
+		prev = best
+	}
+	return append(result, len(xs)-1)
+}
+
+// minMax 分成 n/2 個區間，每區間依時間順序保留最小及最大值
+func minMax(ys []float64, n int) []int {
+	buckets := n / 2
+	size := float64(len(ys)) / float64(buckets)
+	result := make([]int, 0, buckets*2)
+	for b := 0; b < buckets; b++ {
+		from, to := int(float64(b)*size), int(float64(b+1)*size)
+		if b == buckets-1 {
+			to = len(ys)
+		}
+		lo, hi := from, from
+		for i := from; i < to; i++ {
+			if ys[i] < ys[lo] {
+				lo = i
+			}
+			if ys[i] > ys[hi] {
+				hi = i
+			}
+		}
+		switch {
+		case lo == hi:
+			result = append(result, lo)
+		case lo < hi:
+			result = append(result, lo, hi)
+		default:
+			result = append(result, hi, lo)
+		}
+	}
+	return result
+}
+
+// average 從第一筆起以固定間隔平均，點放在區間內讀值時間的平均
+func average(xs []time.Time, ys []float64, interval time.Duration, excess func(float64) float64) ([]time.Time, []float64) {
+	var ax []time.Time
+	var ay []float64
+	for from := 0; from < len(xs); {
+		end := xs[0].Add((xs[from].Sub(xs[0])/interval + 1) * interval)
+		to := from
+		for to < len(xs) && xs[to].Before(end) {
+			to++
+		}
+		if p := peak(ys, from, to, excess); p >= 0 {
+			ax, ay = append(ax, xs[p]), append(ay, ys[p])
+		} else {
+			var sum float64
+			var unix int64
+			for i := from; i < to; i++ {
+				sum += ys[i]
+				unix += xs[i].Unix() - xs[from].Unix()
+			}
+			n := int64(to - from)
+			ax = append(ax, xs[from].Add(time.Duration(unix/n)*time.Second))
+			ay = append(ay, sum/float64(n))
+		}
+		from = to
+	}
+	return ax, ay
+}
+
+// footer 在圖表下方註明降取樣方式
+func (tlc *timeLineState) footer() chart.Renderable {
+	text := fmt.Sprintf(tlc.labels().Downsampled, tlc.Downsample, tlc.sampledTo, tlc.sampledFrom)
+	return func(r chart.Renderer, cb chart.Box, defaults chart.Style) {
+		chart.Draw.Text(r, text, cb.Left, tlc.height-8, chart.Style{
+			Font:      defaults.Font,
+			FontSize:  8,
+			FontColor: chart.ColorAlternateGray,
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package mychart
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wcharczuk/go-chart"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func Test_Downsample(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	newChart := func(d Downsample) *TimeLineChart {
+		line := TimeLine{Name: "Temp", Data: map[int64]float64{}}
+		tlc := &TimeLineChart{UpperValue: 8, LowerValue: 2, Downsample: d, MaxPoints: 200, Width: 600, Height: 300}
+		// 一個月每分鐘一筆，中間有一筆超出上限
+		for i := int64(0); i < 30*1440; i++ {
+			ts := start + i*60
+			tlc.TimestampList = append(tlc.TimestampList, ts)
+			line.Data[ts] = 5 + 2*math.Sin(float64(i)/500)
+		}
+		line.Data[start+12345*60] = 9.5
+		tlc.TimeData = []TimeLine{line}
+		return tlc
+	}
+	for _, d := range []Downsample{DownsampleLTTB, DownsampleMinMax, DownsampleAverage} {
+		s := newChart(d).newState()
+		series := s.getTimeSeries()
+		ts := series[0].(chart.TimeSeries)
+		assert.LessOrEqual(t, ts.Len(), 201, d.String())
+		assert.Contains(t, ts.YValues, 9.5, d.String())
+		assert.Equal(t, 30*1440, s.sampledFrom)
+		assert.Equal(t, ts.Len(), s.sampledTo)
+	}
+
+	tlc := newChart(DownsampleNone)
+	assert.Equal(t, 30*1440, tlc.newState().getTimeSeries()[0].(chart.TimeSeries).Len())
+
+	tlc = newChart(DownsampleLTTB)
+	fontFile := t.TempDir() + "/goregular.ttf"
+	assert.NoError(t, os.WriteFile(fontFile, goregular.TTF, 0o644))
+	var buf bytes.Buffer
+	assert.NoError(t, tlc.Draw(fontFile, &buf))
+	assert.NotZero(t, buf.Len())
+}
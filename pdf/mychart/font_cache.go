@@ -0,0 +1,57 @@
+package mychart
+
+import (
+	"sync"
+
+	"github.com/golang/freetype/truetype"
+)
+
+// FontCache 快取解析後的字型，同一個檔案只讀取一次，可由多個 goroutine 共用
+type FontCache struct {
+	mu    sync.Mutex
+	fonts map[string]*truetype.Font
+	plots map[string]*plotFont
+}
+
+// DefaultFontCache 未指定 FontCache 時使用
+var DefaultFontCache = NewFontCache()
+
+func NewFontCache() *FontCache {
+	return &FontCache{
+		fonts: map[string]*truetype.Font{},
+		plots: map[string]*plotFont{},
+	}
+}
+
+// Font 供 go-chart 使用的字型，fontfile 為空字串時回傳 nil 使用預設字型
+func (fc *FontCache) Font(fontfile string) (*truetype.Font, error) {
+	if fontfile == "" {
+		return nil, nil
+	}
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if f, ok := fc.fonts[fontfile]; ok {
+		return f, nil
+	}
+	f, err := readFont(fontfile)
+	if err != nil {
+		return nil, err
+	}
+	fc.fonts[fontfile] = f
+	return f, nil
+}
+
+// plotFont 供 gonum plot 及向量輸出使用的字型
+func (fc *FontCache) plotFont(fontfile string) (*plotFont, error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if pf, ok := fc.plots[fontfile]; ok {
+		return pf, nil
+	}
+	pf, err := loadPlotFont(fontfile)
+	if err != nil {
+		return nil, err
+	}
+	fc.plots[fontfile] = pf
+	return pf, nil
+}
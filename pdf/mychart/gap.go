@@ -0,0 +1,101 @@
+package mychart
+
+import (
+	"math"
+	"time"
+
+	"github.com/94peter/export/pdf/style"
+	"github.com/wcharczuk/go-chart"
+)
+
+// maxGap 相鄰資料的最大間隔 (秒)
+func (tlc *TimeLineChart) maxGap() int64 {
+	return int64(tlc.Interval.Seconds() * 1.5)
+}
+
+func (tl *TimeLine) value(ts int64) (float64, bool) {
+	v, ok := tl.Data[ts]
+	return v, ok && !math.IsNaN(v)
+}
+
+// getSegmentSeries 依資料中斷將每條線拆成多段，只有第一段顯示於圖例，沒有資料的時間不計入最大最小值
+func (tlc *timeLineState) getSegmentSeries() []chart.Series {
+	palette := style.Palette(len(tlc.TimeData))
+	maxGap := tlc.maxGap()
+	var result []chart.Series
+	for i := range tlc.TimeData {
+		line := &tlc.TimeData[i]
+		var xs []time.Time
+		var ys []float64
+		last := int64(0)
+		named := false
+		flush := func() {
+			if len(xs) > 0 {
+				sx, sy := tlc.reduce(xs, ys, line.Secondary)
+				result = append(result, line.series(sx, sy, palette[i], !named))
+				xs, ys, named = nil, nil, true
+			}
+		}
+		for _, ts := range tlc.TimestampList {
+			v, ok := line.value(ts)
+			if !ok {
+				flush()
+				continue
+			}
+			if len(xs) > 0 && ts-last > maxGap {
+				flush()
+			}
+			xs = append(xs, time.Unix(ts, 0))
+			ys = append(ys, v)
+			tlc.track(line.Secondary, v)
+			last = ts
+		}
+		flush()
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// Gaps 資料中斷的區間 (秒)，以中斷前後的資料時間表示，未設定 Interval 時為 nil
+func (tlc *TimeLineChart) Gaps() [][2]int64 {
+	if tlc.Interval <= 0 {
+		return nil
+	}
+	maxGap := tlc.maxGap()
+	var gaps [][2]int64
+	prev, found := int64(0), false
+	for _, ts := range tlc.TimestampList {
+		has := false
+		for i := range tlc.TimeData {
+			if _, ok := tlc.TimeData[i].value(ts); ok {
+				has = true
+				break
+			}
+		}
+		if !has {
+			continue
+		}
+		if found && ts-prev > maxGap {
+			gaps = append(gaps, [2]int64{prev, ts})
+		}
+		prev, found = ts, true
+	}
+	return gaps
+}
+
+// gapBands 以半透明色塊標示資料中斷
+func (tlc *TimeLineChart) gapBands() chart.Series {
+	gaps := tlc.Gaps()
+	fill := annotationColor(tlc.GapColor, style.ColorGray.WithAlpha(0.5))
+	return layer{render: func(r chart.Renderer, cb chart.Box, x func(int64) int, _ func(float64) int, _ chart.Style) {
+		for _, g := range gaps {
+			chart.Draw.Box(r, chart.Box{Top: cb.Top, Left: x(g[0]), Right: x(g[1]), Bottom: cb.Bottom}, chart.Style{
+				FillColor:   fill,
+				StrokeColor: fill,
+				StrokeWidth: 0,
+			})
+		}
+	}}
+}
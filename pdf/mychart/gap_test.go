@@ -0,0 +1,46 @@
+package mychart
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func Test_TimeLineGap(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	tlc := &TimeLineChart{
+		NoUpperLower: true,
+		Interval:     10 * time.Minute,
+		Width:        600,
+		Height:       300,
+	}
+	line := TimeLine{Name: "A", Data: map[int64]float64{}}
+	for i := int64(0); i < 12; i++ {
+		ts := start + i*600
+		tlc.TimestampList = append(tlc.TimestampList, ts)
+		// 第 4 到 6 筆沒有資料
+		if i < 4 || i > 6 {
+			line.Data[ts] = float64(i)
+		}
+	}
+	tlc.TimeData = []TimeLine{line}
+
+	assert.Equal(t, [][2]int64{{start + 3*600, start + 7*600}}, tlc.Gaps())
+	s := tlc.newState()
+	series := s.getTimeSeries()
+	assert.Len(t, series, 2)
+	assert.Equal(t, "A", series[0].GetName())
+	assert.Equal(t, "", series[1].GetName())
+	assert.Equal(t, 0.0, s.min)
+	assert.Equal(t, 11.0, s.max)
+
+	fontFile := t.TempDir() + "/goregular.ttf"
+	assert.NoError(t, os.WriteFile(fontFile, goregular.TTF, 0o644))
+	var buf bytes.Buffer
+	assert.NoError(t, tlc.Draw(fontFile, &buf))
+	assert.NotZero(t, buf.Len())
+}
@@ -0,0 +1,122 @@
+package mychart
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/94peter/export/pdf"
+	"github.com/94peter/export/pdf/style"
+
+	"gonum.org/v1/plot/text"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// GaugeBand 儀表的區段，例如低於下限、正常範圍、高於上限
+type GaugeBand struct {
+	From, To float64
+	Color    style.Color
+}
+
+// GaugeChart 半圓儀表，顯示目前值與上下限的關係
+type GaugeChart struct {
+	Title    string
+	Value    float64
+	Min, Max float64
+	// 未設定時整個刻度為灰色
+	Bands []GaugeBand
+	Unit  string
+	DP    uint // 小數位數，0 為一位
+
+	// 預設 400*260
+	ChartOptions
+}
+
+// NewLimitGauge 以上下限建立三個區段：低於下限、正常、高於上限，範圍不合理時回傳錯誤
+func NewLimitGauge(value, min, max, lower, upper float64) (*GaugeChart, error) {
+	if min >= max || lower < min || upper > max || lower > upper {
+		return nil, fmt.Errorf("gauge chart: invalid range %v-%v with limits %v-%v", min, max, lower, upper)
+	}
+	return &GaugeChart{
+		Value: value,
+		Min:   min,
+		Max:   max,
+		Bands: []GaugeBand{
+			{From: min, To: lower, Color: style.ColorCoolAlert},
+			{From: lower, To: upper, Color: style.ColorGray},
+			{From: upper, To: max, Color: style.ColorHeatAlert},
+		},
+	}, nil
+}
+
+// Draw 依 Format 輸出，fontfile 為空字串時使用內建字型
+func (gc *GaugeChart) Draw(fontfile string, w io.Writer) error {
+	width, height := gc.size(400, 260)
+	if err := gc.render(w, fontfile, width, height, gc.paint); err != nil {
+		return fmt.Errorf("gauge chart: %w", err)
+	}
+	return nil
+}
+
+// DrawPDF 畫入 PDF 頁面的矩形
+func (gc *GaugeChart) DrawPDF(p pdf.PDF, fontfile string, x, y, w, h float64) error {
+	if err := gc.renderPDF(p, fontfile, x, y, w, h, gc.paint); err != nil {
+		return fmt.Errorf("gauge chart: %w", err)
+	}
+	return nil
+}
+
+// angle 值在半圓上的角度，Min 在左 (π)、Max 在右 (0)，超出範圍時停在兩端
+func (gc *GaugeChart) angle(v float64) float64 {
+	t := (v - gc.Min) / (gc.Max - gc.Min)
+	return math.Pi * (1 - math.Max(0, math.Min(1, t)))
+}
+
+func (gc *GaugeChart) paint(c draw.Canvas, pf *plotFont) error {
+	if gc.Max <= gc.Min {
+		return errors.New("max must be greater than min")
+	}
+	c = pf.title(c, gc.Title)
+	size := c.Size()
+	fontSize := vg.Length(math.Max(8, float64(size.Y)/14))
+	// 下方保留數值文字的空間
+	outer := vg.Length(math.Min(float64(size.X)/2, float64(size.Y-fontSize*3))) * 0.9
+	inner := outer * 0.7
+	center := vg.Point{X: c.Center().X, Y: c.Min.Y + fontSize*2.5}
+
+	bands := gc.Bands
+	if len(bands) == 0 {
+		bands = []GaugeBand{{From: gc.Min, To: gc.Max, Color: style.ColorGray}}
+	}
+	for _, b := range bands {
+		s, e := gc.angle(b.From), gc.angle(b.To)
+		c.SetColor(toColor(b.Color, style.ColorGray))
+		c.Fill(annulus(center, outer, inner, s, s-e))
+	}
+
+	// 指針
+	a := gc.angle(gc.Value)
+	dir := vg.Point{X: vg.Length(math.Cos(a)), Y: vg.Length(math.Sin(a))}
+	normal := vg.Point{X: -dir.Y, Y: dir.X}
+	base := outer * 0.04
+	c.FillPolygon(toColor(style.ColorBlack, style.ColorBlack), []vg.Point{
+		{X: center.X + normal.X*base, Y: center.Y + normal.Y*base},
+		{X: center.X + dir.X*outer*0.95, Y: center.Y + dir.Y*outer*0.95},
+		{X: center.X - normal.X*base, Y: center.Y - normal.Y*base},
+	})
+	c.SetColor(toColor(style.ColorBlack, style.ColorBlack))
+	c.Fill(annulus(center, base*1.5, 0, 0, 2*math.Pi))
+
+	dp := decimals(gc.DP)
+	small := pf.textStyle(fontSize*0.8, style.ColorBlack, text.XCenter, text.YTop)
+	c.FillText(small, vg.Point{X: center.X - (outer+inner)/2, Y: center.Y - 2}, fmt.Sprintf("%.*f", dp, gc.Min))
+	c.FillText(small, vg.Point{X: center.X + (outer+inner)/2, Y: center.Y - 2}, fmt.Sprintf("%.*f", dp, gc.Max))
+	value := fmt.Sprintf("%.*f", dp, gc.Value)
+	if gc.Unit != "" {
+		value += " " + gc.Unit
+	}
+	c.FillText(pf.textStyle(fontSize*1.2, style.ColorBlack, text.XCenter, text.YTop), vg.Point{X: center.X, Y: center.Y - base*2 - 2}, value)
+	return nil
+}
@@ -0,0 +1,479 @@
+package mychart
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/94peter/export/pdf"
+	"github.com/94peter/export/pdf/style"
+
+	"gonum.org/v1/plot/text"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// HeatMapLayout 熱圖的格子排列
+type HeatMapLayout uint8
+
+const (
+	// 月曆，每格一天，每列一週，週一開始
+	HeatMapCalendar HeatMapLayout = iota
+	// 每列一天，每欄一小時
+	HeatMapHourly
+)
+
+// HeatMapValue 格子顏色的依據
+type HeatMapValue uint8
+
+const (
+	// 平均值，色階由 ColorCoolAlert 經白色到 ColorHeatAlert
+	HeatMapAverage HeatMapValue = iota
+	// 警示狀態，有高於上限的讀值為 ColorHeatAlert，低於下限為 ColorCoolAlert，兩者皆有時取次數多者
+	HeatMapAlert
+)
+
+// HeatMapLabels 熱圖上的文字，空字串使用 DefaultHeatMapLabels 的設定
+type HeatMapLabels struct {
+	// 週一到週日
+	Weekdays [7]string
+	// 每小時熱圖的列標題
+	DateFormat string
+	Below      string
+	Normal     string
+	Above      string
+	NoData     string
+}
+
+var DefaultHeatMapLabels = HeatMapLabels{
+	Weekdays:   [7]string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"},
+	DateFormat: "01-02",
+	Below:      "Below lower",
+	Normal:     "Normal",
+	Above:      "Above upper",
+	NoData:     "No data",
+}
+
+// HeatMap 月曆或小時×日的熱圖，輸入與 TimeLine.Data 相同
+type HeatMap struct {
+	Title string
+	// unix 秒數對應的讀值，NaN 視為沒有資料
+	Data map[int64]float64
+	// 顯示期間 [Start, End)，零值時依資料的第一筆及最後一筆
+	Start, End time.Time
+	// 分日及分時使用的時區，nil 為 UTC，不隨執行環境變動
+	Location *time.Location
+	Layout   HeatMapLayout
+	Value    HeatMapValue
+	// 警示狀態的門檻，也是平均值色階的兩端；NoUpperLower 時色階使用資料的最大最小值
+	UpperValue   float64
+	LowerValue   float64
+	NoUpperLower bool
+	// 月曆格內顯示平均值
+	ShowValues bool
+	DP         uint // 小數位數，0 為一位
+	Labels     HeatMapLabels
+
+	// 預設 800*500，DrawPDF 只使用 PDF 的字型
+	ChartOptions
+}
+
+// heatCell 一格的統計，count 為 0 表示沒有資料
+type heatCell struct {
+	row, col int
+	label    string
+	sum      float64
+	count    int
+	heat     int
+	cool     int
+	inPeriod bool
+}
+
+func (c *heatCell) avg() float64 {
+	return c.sum / float64(c.count)
+}
+
+// heatGrid 依 Layout 分好的格子
+type heatGrid struct {
+	rows, cols int
+	cells      []heatCell
+	rowLabels  []string
+	colLabels  []string
+	// 平均值色階的兩端
+	lo, hi float64
+}
+
+func (hm *HeatMap) labels() HeatMapLabels {
+	l, d := hm.Labels, DefaultHeatMapLabels
+	or := func(v *string, def string) {
+		if *v == "" {
+			*v = def
+		}
+	}
+	for i := range l.Weekdays {
+		or(&l.Weekdays[i], d.Weekdays[i])
+	}
+	or(&l.DateFormat, d.DateFormat)
+	or(&l.Below, d.Below)
+	or(&l.Normal, d.Normal)
+	or(&l.Above, d.Above)
+	or(&l.NoData, d.NoData)
+	return l
+}
+
+func (hm *HeatMap) location() *time.Location {
+	if hm.Location != nil {
+		return hm.Location
+	}
+	return time.UTC
+}
+
+// period 顯示期間，對齊到時區內的日
+func (hm *HeatMap) period() (time.Time, time.Time, error) {
+	loc := hm.location()
+	start, end := hm.Start, hm.End
+	if start.IsZero() || end.IsZero() {
+		var keys []int64
+		for ts, v := range hm.Data {
+			if !math.IsNaN(v) {
+				keys = append(keys, ts)
+			}
+		}
+		if len(keys) == 0 {
+			return time.Time{}, time.Time{}, ErrNoData
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+		if start.IsZero() {
+			start = time.Unix(keys[0], 0)
+		}
+		if end.IsZero() {
+			end = time.Unix(keys[len(keys)-1]+1, 0)
+		}
+	}
+	day := func(t time.Time) time.Time {
+		y, m, d := t.In(loc).Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, loc)
+	}
+	from, to := day(start), day(end)
+	if to.Before(end) {
+		to = to.AddDate(0, 0, 1)
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.New("heat map: empty period")
+	}
+	return from, to, nil
+}
+
+// grid 依 Layout 將讀值分到格子
+func (hm *HeatMap) grid() (*heatGrid, error) {
+	from, to, err := hm.period()
+	if err != nil {
+		return nil, err
+	}
+	l := hm.labels()
+	days := 0
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		days++
+	}
+	g := &heatGrid{}
+	// 日期所在的列與欄，first 為月曆第一列的週一
+	first := from.AddDate(0, 0, -((int(from.Weekday()) + 6) % 7))
+	index := func(t time.Time) (int, int) {
+		y, m, d := t.Date()
+		day := time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+		if hm.Layout == HeatMapHourly {
+			return dayDiff(from, day), t.Hour()
+		}
+		n := dayDiff(first, day)
+		return n / 7, n % 7
+	}
+	switch hm.Layout {
+	case HeatMapHourly:
+		g.rows, g.cols = days, 24
+		for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+			g.rowLabels = append(g.rowLabels, d.Format(l.DateFormat))
+		}
+		for h := 0; h < 24; h++ {
+			g.colLabels = append(g.colLabels, strconv.Itoa(h))
+		}
+	default:
+		g.rows, g.cols = (dayDiff(first, to)+6)/7, 7
+		g.colLabels = l.Weekdays[:]
+	}
+	g.cells = make([]heatCell, g.rows*g.cols)
+	for i := range g.cells {
+		g.cells[i].row, g.cells[i].col = i/g.cols, i%g.cols
+	}
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		r, c := index(d)
+		if hm.Layout == HeatMapHourly {
+			for h := 0; h < 24; h++ {
+				g.cells[r*g.cols+h].inPeriod = true
+			}
+			continue
+		}
+		g.cells[r*g.cols+c].inPeriod = true
+		g.cells[r*g.cols+c].label = strconv.Itoa(d.Day())
+	}
+
+	for ts, v := range hm.Data {
+		t := time.Unix(ts, 0).In(from.Location())
+		if math.IsNaN(v) || t.Before(from) || !t.Before(to) {
+			continue
+		}
+		r, c := index(t)
+		cell := &g.cells[r*g.cols+c]
+		cell.sum += v
+		cell.count++
+		switch {
+		case hm.NoUpperLower:
+		case v > hm.UpperValue:
+			cell.heat++
+		case v < hm.LowerValue:
+			cell.cool++
+		}
+	}
+
+	g.lo, g.hi = hm.LowerValue, hm.UpperValue
+	if hm.NoUpperLower {
+		g.lo, g.hi = math.Inf(1), math.Inf(-1)
+		for i := range g.cells {
+			if g.cells[i].count > 0 {
+				g.lo, g.hi = math.Min(g.lo, g.cells[i].avg()), math.Max(g.hi, g.cells[i].avg())
+			}
+		}
+		g.lo, g.hi = axisRange(g.lo, g.hi)
+	}
+	if g.lo == g.hi {
+		g.lo, g.hi = g.lo-1, g.hi+1
+	}
+	return g, nil
+}
+
+func dayDiff(from, to time.Time) int {
+	// 以日期計算，避免日光節約時間的 23 或 25 小時
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// scale 平均值色階，t 為 0-1
+func heatScale(t float64) style.Color {
+	t = math.Max(0, math.Min(1, t))
+	if t < 0.5 {
+		g := style.Gradient{From: style.ColorCoolAlert, To: style.ColorWhite}
+		return g.At(t * 2)
+	}
+	g := style.Gradient{From: style.ColorWhite, To: style.ColorHeatAlert}
+	return g.At((t - 0.5) * 2)
+}
+
+// color 格子的顏色，沒有資料為 ColorGray
+func (hm *HeatMap) color(g *heatGrid, c *heatCell) style.Color {
+	if c.count == 0 {
+		return style.ColorGray
+	}
+	if hm.Value == HeatMapAlert {
+		switch {
+		case c.heat > 0 && c.heat >= c.cool:
+			return style.ColorHeatAlert
+		case c.cool > 0:
+			return style.ColorCoolAlert
+		}
+		return style.ColorWhite
+	}
+	return heatScale((c.avg() - g.lo) / (g.hi - g.lo))
+}
+
+// painter 熱圖的繪圖方式，座標以左上為原點，PNG/SVG 為像素，PDF 為點
+type painter interface {
+	fill(x, y, w, h float64, c style.Color)
+	gradient(x, y, w, h float64, g *style.Gradient)
+	// text 在 (x, y, w, h) 內垂直置中
+	text(s string, x, y, w, h, size float64, align int)
+}
+
+// paint 依序畫標題、欄列標題、格子及色階說明，尺寸放不下格子時回傳錯誤
+func (hm *HeatMap) paint(p painter, g *heatGrid, width, height float64) error {
+	l := hm.labels()
+	const pad, gap = 8.0, 1.5
+	top := pad
+	if hm.Title != "" {
+		top += 26
+	}
+	left := pad
+	if len(g.rowLabels) > 0 {
+		left += 40
+	}
+	headerH, legendH := 16.0, 30.0
+	gw, gh := width-left-pad, height-top-headerH-legendH-pad
+	// 扣掉標題、欄標題及色階後沒有空間畫格子
+	if gw <= 0 || gh <= 0 {
+		return fmt.Errorf("size %vx%v too small for the grid", width, height)
+	}
+	if hm.Title != "" {
+		p.text(hm.Title, 0, pad, width, 18, 14, style.AlignCenter)
+	}
+	cw, ch := gw/float64(g.cols), gh/float64(g.rows)
+	top += headerH
+
+	// 欄太窄時只標示部分欄標題
+	every := max(1, int(math.Ceil(24/cw)))
+	for i, s := range g.colLabels {
+		if i%every == 0 {
+			p.text(s, left+float64(i)*cw, top-headerH, cw, headerH, 9, style.AlignCenter)
+		}
+	}
+	rowEvery := max(1, int(math.Ceil(10/ch)))
+	for i, s := range g.rowLabels {
+		if i%rowEvery == 0 {
+			p.text(s, pad, top+float64(i)*ch, left-pad-4, ch, 9, style.AlignRight)
+		}
+	}
+
+	size := math.Min(10, ch*0.3)
+	for i := range g.cells {
+		c := &g.cells[i]
+		if !c.inPeriod {
+			continue
+		}
+		x, y := left+float64(c.col)*cw, top+float64(c.row)*ch
+		p.fill(x+gap/2, y+gap/2, cw-gap, ch-gap, hm.color(g, c))
+		if c.label != "" {
+			p.text(c.label, x+3, y+2, cw-6, size+2, size, style.AlignLeft)
+		}
+		if hm.ShowValues && c.count > 0 && hm.Layout == HeatMapCalendar {
+			p.text(strconv.FormatFloat(c.avg(), 'f', int(decimals(hm.DP)), 64), x, y, cw, ch, math.Min(12, ch*0.35), style.AlignCenter)
+		}
+	}
+
+	// 色階說明
+	y := height - pad - legendH + 10
+	x := left
+	swatch := func(c style.Color, label string) {
+		// 外框讓白色也看得見
+		p.fill(x-0.5, y-0.5, 13, 13, style.ColorGray)
+		p.fill(x, y, 12, 12, c)
+		p.text(label, x+16, y, 90, 12, 9, style.AlignLeft)
+		x += 16 + float64(len(label))*5.5 + 12
+	}
+	if hm.Value == HeatMapAlert {
+		swatch(style.ColorCoolAlert, l.Below)
+		swatch(style.ColorWhite, l.Normal)
+		swatch(style.ColorHeatAlert, l.Above)
+		swatch(style.ColorGray, l.NoData)
+		return nil
+	}
+	bar := math.Min(gw*0.5, 240)
+	p.text(strconv.FormatFloat(g.lo, 'f', int(decimals(hm.DP)), 64), x, y, 40, 12, 9, style.AlignRight)
+	x += 44
+	p.gradient(x, y, bar/2, 12, &style.Gradient{From: style.ColorCoolAlert, To: style.ColorWhite})
+	p.gradient(x+bar/2, y, bar/2, 12, &style.Gradient{From: style.ColorWhite, To: style.ColorHeatAlert})
+	x += bar + 4
+	p.text(strconv.FormatFloat(g.hi, 'f', int(decimals(hm.DP)), 64), x, y, 40, 12, 9, style.AlignLeft)
+	x += 56
+	swatch(style.ColorGray, l.NoData)
+	return nil
+}
+
+// Draw 依 Format 輸出，fontfile 為空字串時使用內建字型
+func (hm *HeatMap) Draw(fontfile string, w io.Writer) error {
+	g, err := hm.grid()
+	if err != nil {
+		return fmt.Errorf("heat map: %w", err)
+	}
+	width, height := hm.size(800, 500)
+	err = hm.render(w, fontfile, width, height, func(c draw.Canvas, pf *plotFont) error {
+		return hm.paint(&canvasPainter{c: c, font: pf}, g, float64(width), float64(height))
+	})
+	if err != nil {
+		return fmt.Errorf("heat map: %w", err)
+	}
+	return nil
+}
+
+// DrawPDF 以 PDF 的矩形及文字直接畫在頁面的 (x, y) 起寬 w 高 h 的範圍，font 為已載入 PDF 的字型名稱
+func (hm *HeatMap) DrawPDF(p pdf.PDF, font string, x, y, w, h float64) error {
+	tb, ok := p.(pdf.TextBlockDrawer)
+	if !ok {
+		return fmt.Errorf("heat map: %w", errors.ErrUnsupported)
+	}
+	if !pdf.HasFont(p, font) {
+		return fmt.Errorf("heat map: font %q not loaded", font)
+	}
+	if w <= 0 || h <= 0 {
+		return fmt.Errorf("heat map: invalid size %vx%v", w, h)
+	}
+	g, err := hm.grid()
+	if err != nil {
+		return fmt.Errorf("heat map: %w", err)
+	}
+	if err = hm.paint(&pdfPainter{p: tb, font: font, x: x, y: y}, g, w, h); err != nil {
+		return fmt.Errorf("heat map: %w", err)
+	}
+	return nil
+}
+
+// canvasPainter 畫在 gonum 的畫布上，座標單位為像素
+type canvasPainter struct {
+	c    draw.Canvas
+	font *plotFont
+}
+
+func (cp *canvasPainter) rect(x, y, w, h float64) []vg.Point {
+	x0, y0 := cp.c.Min.X+length(x), cp.c.Max.Y-length(y)
+	x1, y1 := x0+length(w), y0-length(h)
+	return []vg.Point{{X: x0, Y: y0}, {X: x1, Y: y0}, {X: x1, Y: y1}, {X: x0, Y: y1}}
+}
+
+func (cp *canvasPainter) fill(x, y, w, h float64, c style.Color) {
+	cp.c.FillPolygon(toColor(c, style.ColorWhite), cp.rect(x, y, w, h))
+}
+
+func (cp *canvasPainter) gradient(x, y, w, h float64, g *style.Gradient) {
+	steps := int(math.Max(2, math.Ceil(w)))
+	step := w / float64(steps)
+	for i := 0; i < steps; i++ {
+		// 稍微重疊避免細縫
+		cp.fill(x+float64(i)*step, y, step+0.5, h, g.At(float64(i)/float64(steps-1)))
+	}
+}
+
+func (cp *canvasPainter) text(s string, x, y, w, h, size float64, align int) {
+	xAlign, px := text.XLeft, x
+	switch align {
+	case style.AlignCenter:
+		xAlign, px = text.XCenter, x+w/2
+	case style.AlignRight:
+		xAlign, px = text.XRight, x+w
+	}
+	ts := cp.font.textStyle(length(size), style.ColorBlack, xAlign, text.YCenter)
+	cp.c.FillText(ts, vg.Point{X: cp.c.Min.X + length(px), Y: cp.c.Max.Y - length(y+h/2)}, s)
+}
+
+// pdfPainter 以 TextBlockXY 畫在 PDF 頁面，座標單位為點
+type pdfPainter struct {
+	p    pdf.TextBlockDrawer
+	font string
+	x, y float64
+}
+
+func (pp *pdfPainter) fill(x, y, w, h float64, c style.Color) {
+	pp.p.TextBlockXY("", style.TextBlockStyle{BackGround: c}, pp.x+x, pp.y+y, w, h, style.AlignLeft, style.ValignTop)
+}
+
+func (pp *pdfPainter) gradient(x, y, w, h float64, g *style.Gradient) {
+	pp.p.TextBlockXY("", style.TextBlockStyle{Gradient: g}, pp.x+x, pp.y+y, w, h, style.AlignLeft, style.ValignTop)
+}
+
+func (pp *pdfPainter) text(s string, x, y, w, h, size float64, align int) {
+	ts := style.TextBlockStyle{
+		TextStyle: style.TextStyle{Font: pp.font, FontSize: int(math.Round(size)), Color: style.ColorBlack},
+		Padding:   &style.Padding{},
+	}
+	pp.p.TextBlockXY(s, ts, pp.x+x, pp.y+y, w, h, align, style.ValignMiddle)
+}
@@ -0,0 +1,87 @@
+package mychart
+
+import (
+	"bytes"
+	"image/png"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/94peter/export/pdf"
+	"github.com/94peter/export/pdf/style"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func Test_HeatMap(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	start := time.Date(2023, 3, 1, 0, 0, 0, 0, loc)
+	data := map[int64]float64{}
+	for ts := start; ts.Before(start.AddDate(0, 1, 0)); ts = ts.Add(30 * time.Minute) {
+		data[ts.Unix()] = 5 + 2*math.Sin(float64(ts.Hour())/24*2*math.Pi)
+	}
+	// 3/8 10 點超過上限，3/20 整天沒有資料
+	data[time.Date(2023, 3, 8, 10, 0, 0, 0, loc).Unix()] = 12
+	for ts := time.Date(2023, 3, 20, 0, 0, 0, 0, loc); ts.Day() == 20; ts = ts.Add(30 * time.Minute) {
+		data[ts.Unix()] = math.NaN()
+	}
+	hm := &HeatMap{
+		Title:        "March",
+		Data:         data,
+		Location:     loc,
+		Value:        HeatMapAlert,
+		UpperValue:   8,
+		LowerValue:   2,
+		ShowValues:   true,
+		ChartOptions: ChartOptions{Width: 500, Height: 360},
+	}
+	g, err := hm.grid()
+	assert.NoError(t, err)
+	// 3/1 為週三
+	assert.Equal(t, 5, g.rows)
+	hot := &g.cells[1*7+2]
+	assert.Equal(t, "8", hot.label)
+	assert.Equal(t, style.ColorHeatAlert, hm.color(g, hot))
+	assert.Equal(t, style.ColorWhite, hm.color(g, &g.cells[1*7+3]))
+	assert.Equal(t, style.ColorGray, hm.color(g, &g.cells[3*7+0]))
+	assert.False(t, g.cells[0].inPeriod)
+
+	fontFile := t.TempDir() + "/goregular.ttf"
+	assert.NoError(t, os.WriteFile(fontFile, goregular.TTF, 0o644))
+	var buf bytes.Buffer
+	assert.NoError(t, hm.Draw(fontFile, &buf))
+	img, err := png.Decode(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 500, img.Bounds().Dx())
+
+	hm.Layout, hm.Value, hm.NoUpperLower = HeatMapHourly, HeatMapAverage, true
+	g, err = hm.grid()
+	assert.NoError(t, err)
+	assert.Equal(t, 31, g.rows)
+	assert.Equal(t, 24, g.cols)
+	buf.Reset()
+	hm.Format = FormatSVG
+	assert.NoError(t, hm.Draw(fontFile, &buf))
+	assert.True(t, strings.Contains(buf.String(), "<svg"))
+
+	p := pdf.NewPDFv2(map[string]string{"r": fontFile}, 20, 20, 20, 20)
+	p.AddDirectPage()
+	assert.Error(t, hm.DrawPDF(p, "missing", 20, 20, 500, 360))
+	assert.NoError(t, hm.DrawPDF(p, "r", 20, 20, 500, 360))
+	buf.Reset()
+	assert.NoError(t, p.Write(&buf))
+	assert.Equal(t, 0, strings.Count(buf.String(), "/Subtype /Image"))
+
+	assert.ErrorIs(t, (&HeatMap{}).Draw("", &buf), ErrNoData)
+
+	// 矩形扣掉標題、欄標題及色階後沒有格子空間時回傳錯誤，不可除以零
+	day := &HeatMap{Data: map[int64]float64{start.Unix(): 5}, Location: loc, Layout: HeatMapHourly}
+	assert.NoError(t, day.DrawPDF(p, "r", 20, 20, 300, 80))
+	assert.ErrorContains(t, day.DrawPDF(p, "r", 20, 20, 300, 40), "too small")
+	day.Title = "Day"
+	assert.ErrorContains(t, day.DrawPDF(p, "r", 20, 20, 300, 80), "too small")
+	day.Width, day.Height = 40, 300
+	assert.ErrorContains(t, day.Draw(fontFile, &buf), "too small")
+}
@@ -2,6 +2,7 @@ package mychart
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -60,7 +61,7 @@ func Test_Chart(t *testing.T) {
 		},
 	}
 
-	f, _ := os.Create("output.png")
+	f, _ := os.Create(filepath.Join(t.TempDir(), "output.png"))
 	defer f.Close()
 	graph.Render(chart.PNG, f)
 }
@@ -107,7 +108,7 @@ func Test_Line(t *testing.T) {
 		},
 	}
 
-	f, _ := os.Create("line.png")
+	f, _ := os.Create(filepath.Join(t.TempDir(), "line.png"))
 	defer f.Close()
 
 	tlc.Draw("../../resource/TW-Medium.ttf", f)
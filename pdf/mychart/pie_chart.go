@@ -0,0 +1,151 @@
+package mychart
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/94peter/export/pdf"
+	"github.com/94peter/export/pdf/style"
+
+	"gonum.org/v1/plot/text"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// PieSlice 圓餅圖的一塊，未設定顏色時使用 style.Palette
+type PieSlice struct {
+	Label string
+	Value float64
+	Color style.Color
+}
+
+// PieChart 圓餅圖，設定 Hole 時為甜甜圈圖
+type PieChart struct {
+	Title  string
+	Slices []PieSlice
+	// 內圈半徑佔外圈的比例 0-1
+	Hole float64
+	// 甜甜圈中間的文字
+	CenterText string
+	// 於各塊顯示百分比
+	ShowPercent bool
+	DP          uint // 百分比小數位數，0 為一位
+
+	// 預設 600*400
+	ChartOptions
+}
+
+// Draw 依 Format 輸出，fontfile 為空字串時使用內建字型
+func (pc *PieChart) Draw(fontfile string, w io.Writer) error {
+	width, height := pc.size(600, 400)
+	if err := pc.render(w, fontfile, width, height, pc.paint); err != nil {
+		return fmt.Errorf("pie chart: %w", err)
+	}
+	return nil
+}
+
+// DrawPDF 畫入 PDF 頁面的矩形
+func (pc *PieChart) DrawPDF(p pdf.PDF, fontfile string, x, y, w, h float64) error {
+	if err := pc.renderPDF(p, fontfile, x, y, w, h, pc.paint); err != nil {
+		return fmt.Errorf("pie chart: %w", err)
+	}
+	return nil
+}
+
+// annulus 由角度 s 順時針掃過 a 的環形區塊，inner 為 0 時為扇形
+func annulus(center vg.Point, outer, inner vg.Length, s, a float64) vg.Path {
+	var path vg.Path
+	at := func(r vg.Length, angle float64) vg.Point {
+		return vg.Point{X: center.X + r*vg.Length(math.Cos(angle)), Y: center.Y + r*vg.Length(math.Sin(angle))}
+	}
+	path.Move(at(outer, s))
+	path.Arc(center, outer, s, -a)
+	if inner > 0 {
+		path.Line(at(inner, s-a))
+		path.Arc(center, inner, s-a, a)
+	} else {
+		path.Line(center)
+	}
+	path.Close()
+	return path
+}
+
+func (pf *plotFont) textStyle(size vg.Length, c style.Color, xAlign text.XAlignment, yAlign text.YAlignment) text.Style {
+	var s text.Style
+	pf.style(&s, size)
+	s.Color = toColor(c, style.ColorBlack)
+	s.XAlign, s.YAlign = xAlign, yAlign
+	return s
+}
+
+// title 畫標題並回傳剩下的繪圖區
+func (pf *plotFont) title(c draw.Canvas, title string) draw.Canvas {
+	if title == "" {
+		return c
+	}
+	size := vg.Length(14)
+	c.FillText(pf.textStyle(size, style.ColorBlack, text.XCenter, text.YTop), vg.Point{X: c.Center().X, Y: c.Max.Y - 4}, title)
+	c.Max.Y -= size * 1.8
+	return c
+}
+
+func (pc *PieChart) paint(c draw.Canvas, pf *plotFont) error {
+	total := 0.0
+	for _, s := range pc.Slices {
+		if s.Value < 0 {
+			return fmt.Errorf("negative value %v of %s", s.Value, s.Label)
+		}
+		total += s.Value
+	}
+	if total == 0 {
+		return errors.New("no data")
+	}
+	c = pf.title(c, pc.Title)
+	// 左側為圓，右側為圖例
+	legendW := c.Size().X * 0.35
+	area := c
+	area.Max.X -= legendW
+	size := area.Size()
+	outer := vg.Length(math.Min(float64(size.X), float64(size.Y))) / 2 * 0.9
+	inner := outer * vg.Length(math.Max(0, math.Min(pc.Hole, 0.95)))
+	center := area.Center()
+
+	palette := style.Palette(len(pc.Slices))
+	angle := math.Pi / 2
+	dp := decimals(pc.DP)
+	fontSize := vg.Length(math.Max(8, float64(outer)/10))
+	for i, s := range pc.Slices {
+		sweep := s.Value / total * 2 * math.Pi
+		c.SetColor(toColor(s.Color, palette[i]))
+		c.Fill(annulus(center, outer, inner, angle, sweep))
+		if pc.ShowPercent && sweep > 0.2 {
+			mid := angle - sweep/2
+			r := (outer + inner) / 2
+			if inner == 0 {
+				r = outer * 0.65
+			}
+			pt := vg.Point{X: center.X + r*vg.Length(math.Cos(mid)), Y: center.Y + r*vg.Length(math.Sin(mid))}
+			c.FillText(pf.textStyle(fontSize*0.8, style.ColorWhite, text.XCenter, text.YCenter), pt,
+				fmt.Sprintf("%.*f%%", dp, s.Value/total*100))
+		}
+		angle -= sweep
+	}
+	if pc.CenterText != "" && inner > 0 {
+		c.FillText(pf.textStyle(fontSize, style.ColorBlack, text.XCenter, text.YCenter), center, pc.CenterText)
+	}
+
+	// 圖例
+	box := fontSize
+	y := center.Y + vg.Length(len(pc.Slices)-1)*box*0.9
+	x := area.Max.X + box
+	for i, s := range pc.Slices {
+		c.FillPolygon(toColor(s.Color, palette[i]), []vg.Point{
+			{X: x, Y: y - box/2}, {X: x + box, Y: y - box/2}, {X: x + box, Y: y + box/2}, {X: x, Y: y + box/2},
+		})
+		c.FillText(pf.textStyle(fontSize*0.9, style.ColorBlack, text.XLeft, text.YCenter), vg.Point{X: x + box*1.5, Y: y}, s.Label)
+		y -= box * 1.8
+	}
+	return nil
+}
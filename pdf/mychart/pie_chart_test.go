@@ -0,0 +1,64 @@
+package mychart
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/94peter/export/pdf"
+	"github.com/94peter/export/pdf/style"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PieGauge(t *testing.T) {
+	pc := &PieChart{
+		Title:       "Time in range",
+		Hole:        0.5,
+		CenterText:  "92%",
+		ShowPercent: true,
+		Slices: []PieSlice{
+			{Label: "In range", Value: 92},
+			{Label: "Above", Value: 5, Color: style.ColorHeatAlert},
+			{Label: "Below", Value: 3, Color: style.ColorCoolAlert},
+		},
+		ChartOptions: ChartOptions{Width: 300, Height: 200, Fonts: NewFontCache()},
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, pc.Draw("", &buf))
+	img, err := png.Decode(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 300, img.Bounds().Dx())
+
+	pc.Scale = 2
+	buf.Reset()
+	assert.NoError(t, pc.Draw("", &buf))
+	img, err = png.Decode(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 600, img.Bounds().Dx())
+
+	pc.Format = FormatSVG
+	buf.Reset()
+	assert.NoError(t, pc.Draw("", &buf))
+	assert.True(t, strings.Contains(buf.String(), "<svg"))
+	pc.Format = "gif"
+	assert.Error(t, pc.Draw("", &buf))
+
+	assert.Error(t, (&PieChart{}).Draw("", &buf))
+
+	gc, err := NewLimitGauge(7.3, 0, 10, 2, 8)
+	assert.NoError(t, err)
+	gc.Unit = "°C"
+	p := pdf.NewPDFv2(nil, 20, 20, 20, 20)
+	p.AddDirectPage()
+	assert.NoError(t, gc.DrawPDF(p, "", 20, 20, 200, 130))
+	assert.NoError(t, pc.DrawPDF(p, "", 220, 20, 200, 130))
+	buf.Reset()
+	assert.NoError(t, p.Write(&buf))
+	assert.Equal(t, 2, strings.Count(buf.String(), "/Subtype /Image"))
+
+	_, err = NewLimitGauge(0, 10, 0, 2, 8)
+	assert.Error(t, err)
+	_, err = NewLimitGauge(0, 0, 10, 8, 2)
+	assert.Error(t, err)
+}
@@ -0,0 +1,61 @@
+package mychart
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/image/font/opentype"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/font"
+	"gonum.org/v1/plot/text"
+	"gonum.org/v1/plot/vg"
+)
+
+// 圖表以像素設定尺寸，gonum plot 的 PNG 為 96 DPI
+func pixels(px int) vg.Length {
+	return vg.Length(px) * vg.Inch / 96
+}
+
+// plotFont 讀取 TrueType 字型供 gonum plot 使用，fontfile 為空字串時使用內建字型
+type plotFont struct {
+	handler text.Handler
+	font    font.Font
+	cache   *font.Cache
+}
+
+func loadPlotFont(fontfile string) (*plotFont, error) {
+	if fontfile == "" {
+		return &plotFont{handler: plot.DefaultTextHandler, font: plot.DefaultFont, cache: font.DefaultCache}, nil
+	}
+	data, err := os.ReadFile(fontfile)
+	if err != nil {
+		return nil, fmt.Errorf("font %s: %w", fontfile, err)
+	}
+	otf, err := opentype.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("font %s: %w", fontfile, err)
+	}
+	f := font.Font{Typeface: font.Typeface(fontfile)}
+	cache := font.NewCache(font.Collection{{Font: f, Face: otf}})
+	return &plotFont{handler: text.Plain{Fonts: cache}, font: f, cache: cache}, nil
+}
+
+func (pf *plotFont) face(size vg.Length) font.Face {
+	return pf.cache.Lookup(pf.font, size)
+}
+
+func (pf *plotFont) style(s *text.Style, size vg.Length) {
+	s.Handler = pf.handler
+	s.Font = font.From(pf.font, size)
+}
+
+// apply 將標題、座標軸及圖例改用此字型
+func (pf *plotFont) apply(p *plot.Plot) {
+	p.TextHandler = pf.handler
+	pf.style(&p.Title.TextStyle, 14)
+	for _, a := range []*plot.Axis{&p.X, &p.Y} {
+		pf.style(&a.Label.TextStyle, 11)
+		pf.style(&a.Tick.Label, 9)
+	}
+	pf.style(&p.Legend.TextStyle, 10)
+}
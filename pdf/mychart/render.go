@@ -0,0 +1,92 @@
+package mychart
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/94peter/export/pdf"
+
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/vgimg"
+)
+
+// 畫入 PDF 時每點的像素數
+const pdfPixelPerPoint = 2
+
+// ChartOptions 以 gonum 畫布繪製的圖表共用的尺寸、輸出格式及字型設定
+type ChartOptions struct {
+	// 像素，0 時使用各圖表的預設值
+	Width  int
+	Height int
+	// 輸出格式，空字串為 PNG
+	Format Format
+	// PNG 的解析度倍數，例如 2 輸出兩倍像素，0 為 1
+	Scale float64
+	// 字型快取，nil 時使用 DefaultFontCache
+	Fonts *FontCache
+}
+
+// decimals 數值標籤的小數位數，與 TimeLineChart 相同 0 為一位
+func decimals(dp uint) uint {
+	if dp == 0 {
+		return 1
+	}
+	return dp
+}
+
+// size 未設定的寬高使用 w、h
+func (o *ChartOptions) size(w, h int) (int, int) {
+	if o.Width != 0 {
+		w = o.Width
+	}
+	if o.Height != 0 {
+		h = o.Height
+	}
+	return w, h
+}
+
+func (o *ChartOptions) fonts() *FontCache {
+	if o.Fonts != nil {
+		return o.Fonts
+	}
+	return DefaultFontCache
+}
+
+// render 依 Format 及 Scale 輸出 width*height 像素的圖
+func (o *ChartOptions) render(w io.Writer, fontfile string, width, height int, fn func(c draw.Canvas, pf *plotFont) error) error {
+	pf, err := o.fonts().plotFont(fontfile)
+	if err != nil {
+		return err
+	}
+	cw, err := newCanvas(o.Format, width, height, o.Scale)
+	if err != nil {
+		return err
+	}
+	if err = fn(draw.New(cw), pf); err != nil {
+		return err
+	}
+	_, err = cw.WriteTo(w)
+	return err
+}
+
+// renderPDF 以 PNG 畫入 PDF 頁面 (x, y) 起寬 w 高 h 的矩形，解析度為每點兩個像素，不受 Format 及 Scale 影響
+func (o *ChartOptions) renderPDF(p pdf.PDF, fontfile string, x, y, w, h float64, fn func(c draw.Canvas, pf *plotFont) error) error {
+	if w <= 0 || h <= 0 {
+		return fmt.Errorf("invalid size %vx%v", w, h)
+	}
+	pf, err := o.fonts().plotFont(fontfile)
+	if err != nil {
+		return err
+	}
+	c := vgimg.NewWith(vgimg.UseWH(vg.Length(w), vg.Length(h)), vgimg.UseDPI(72*pdfPixelPerPoint))
+	if err = fn(draw.New(c), pf); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if _, err = (vgimg.PngCanvas{Canvas: c}).WriteTo(&buf); err != nil {
+		return err
+	}
+	return pdf.ImageReaderRect(p, &buf, x, y, w, h)
+}
@@ -0,0 +1,182 @@
+package mychart
+
+import (
+	"time"
+
+	"github.com/wcharczuk/go-chart"
+)
+
+// ChartLabels 圖表上的文字及時間格式，空字串使用 DefaultChartLabels 的設定
+type ChartLabels struct {
+	Time       string
+	LowerLimit string
+	UpperLimit string
+	CILower    string
+	CIUpper    string
+	// 降取樣註記，依序帶入方式、畫出的點數及原始點數
+	Downsampled string
+
+	// 刻度間隔小於一天且時間跨度在一天內
+	TimeFormat string
+	// 刻度間隔小於一天且時間跨度超過一天
+	DateTimeFormat string
+	// 刻度間隔為天
+	DateFormat string
+	// 刻度間隔為月
+	MonthFormat string
+}
+
+var DefaultChartLabels = ChartLabels{
+	Time:       "Time",
+	LowerLimit: "Lower Limit",
+	UpperLimit: "Upper Limit",
+	CILower:    "-1.96D",
+	CIUpper:    "1.96D",
+
+	Downsampled: "Down-sampled (%s): %d of %d points shown",
+
+	TimeFormat:     "15:04",
+	DateTimeFormat: "01-02 15:04",
+	DateFormat:     "01-02",
+	MonthFormat:    "2006-01",
+}
+
+func (tlc *TimeLineChart) labels() ChartLabels {
+	return tlc.Labels.withDefaults()
+}
+
+// withDefaults 空字串改用 DefaultChartLabels 的設定
+func (l ChartLabels) withDefaults() ChartLabels {
+	d := DefaultChartLabels
+	or := func(v *string, def string) {
+		if *v == "" {
+			*v = def
+		}
+	}
+	or(&l.Time, d.Time)
+	or(&l.LowerLimit, d.LowerLimit)
+	or(&l.UpperLimit, d.UpperLimit)
+	or(&l.CILower, d.CILower)
+	or(&l.CIUpper, d.CIUpper)
+	or(&l.Downsampled, d.Downsampled)
+	or(&l.TimeFormat, d.TimeFormat)
+	or(&l.DateTimeFormat, d.DateTimeFormat)
+	or(&l.DateFormat, d.DateFormat)
+	or(&l.MonthFormat, d.MonthFormat)
+	return l
+}
+
+func (tlc *TimeLineChart) location() *time.Location {
+	if tlc.Location != nil {
+		return tlc.Location
+	}
+	return time.UTC
+}
+
+type tickUnit int
+
+const (
+	unitMinute tickUnit = iota
+	unitHour
+	unitDay
+	unitMonth
+)
+
+// tickStep 刻度間隔，以日曆單位計算
+type tickStep struct {
+	unit tickUnit
+	n    int
+}
+
+var tickSteps = []tickStep{
+	{unitMinute, 1}, {unitMinute, 5}, {unitMinute, 10}, {unitMinute, 15}, {unitMinute, 30},
+	{unitHour, 1}, {unitHour, 2}, {unitHour, 3}, {unitHour, 6}, {unitHour, 12},
+	{unitDay, 1}, {unitDay, 2}, {unitDay, 7}, {unitDay, 14},
+	{unitMonth, 1}, {unitMonth, 2}, {unitMonth, 3}, {unitMonth, 6}, {unitMonth, 12},
+}
+
+// approx 約略長度，用於選擇間隔
+func (s tickStep) approx() time.Duration {
+	switch s.unit {
+	case unitMinute:
+		return time.Duration(s.n) * time.Minute
+	case unitHour:
+		return time.Duration(s.n) * time.Hour
+	case unitDay:
+		return time.Duration(s.n) * 24 * time.Hour
+	}
+	return time.Duration(s.n) * 30 * 24 * time.Hour
+}
+
+// floor 取得不晚於 t 且對齊日曆的刻度，週以週一對齊
+func (s tickStep) floor(t time.Time) time.Time {
+	y, m, d := t.Date()
+	loc := t.Location()
+	switch s.unit {
+	case unitMinute:
+		return time.Date(y, m, d, t.Hour(), t.Minute()-t.Minute()%s.n, 0, 0, loc)
+	case unitHour:
+		return time.Date(y, m, d, t.Hour()-t.Hour()%s.n, 0, 0, 0, loc)
+	case unitDay:
+		if s.n == 7 || s.n == 14 {
+			return time.Date(y, m, d-(int(t.Weekday())+6)%7, 0, 0, 0, 0, loc)
+		}
+		return time.Date(y, m, d-(d-1)%s.n, 0, 0, 0, 0, loc)
+	}
+	return time.Date(y, m-(m-1)%time.Month(s.n), 1, 0, 0, 0, 0, loc)
+}
+
+func (s tickStep) next(t time.Time) time.Time {
+	y, m, d := t.Date()
+	switch s.unit {
+	case unitMinute:
+		return time.Date(y, m, d, t.Hour(), t.Minute()+s.n, 0, 0, t.Location())
+	case unitHour:
+		return time.Date(y, m, d, t.Hour()+s.n, 0, 0, 0, t.Location())
+	case unitDay:
+		// 每月重新對齊，避免跨月時出現間隔不一的刻度
+		if n := time.Date(y, m, d+s.n, 0, 0, 0, 0, t.Location()); s.n == 1 || s.n == 7 || s.n == 14 || n.Month() == m {
+			return n
+		}
+		return time.Date(y, m+1, 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(y, m+time.Month(s.n), 1, 0, 0, 0, 0, t.Location())
+}
+
+func (s tickStep) format(l ChartLabels, span time.Duration) string {
+	switch s.unit {
+	case unitDay:
+		return l.DateFormat
+	case unitMonth:
+		return l.MonthFormat
+	}
+	if span <= 24*time.Hour {
+		return l.TimeFormat
+	}
+	return l.DateTimeFormat
+}
+
+// timeTicks 依時間跨度選擇刻度間隔，刻度對齊時區內的整點、日或月
+func (tlc *TimeLineChart) timeTicks(from, to time.Time, maxTicks int) []chart.Tick {
+	span := to.Sub(from)
+	if span <= 0 || maxTicks < 2 {
+		return nil
+	}
+	step := tickSteps[len(tickSteps)-1]
+	for _, s := range tickSteps {
+		if int(span/s.approx()) < maxTicks {
+			step = s
+			break
+		}
+	}
+	layout := step.format(tlc.labels(), span)
+	loc := tlc.location()
+	var ticks []chart.Tick
+	for t := step.floor(from.In(loc)); !t.After(to); t = step.next(t) {
+		if t.Before(from) {
+			continue
+		}
+		ticks = append(ticks, chart.Tick{Value: float64(t.UnixNano()), Label: t.Format(layout)})
+	}
+	return ticks
+}
@@ -0,0 +1,41 @@
+package mychart
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tickLabels(tlc *TimeLineChart, from, to time.Time, maxTicks int) []string {
+	var labels []string
+	for _, t := range tlc.timeTicks(from, to, maxTicks) {
+		labels = append(labels, t.Label)
+	}
+	return labels
+}
+
+func Test_TimeTicks(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	tlc := &TimeLineChart{Location: loc}
+	// UTC 16:10 為當地 00:10
+	from := time.Date(2024, 1, 1, 16, 10, 0, 0, time.UTC)
+
+	assert.Equal(t, []string{"02:00", "04:00", "06:00"}, tickLabels(tlc, from, from.Add(6*time.Hour), 6))
+	assert.Equal(t, []string{"01-03", "01-04", "01-05"}, tickLabels(tlc, from, from.Add(72*time.Hour), 6))
+	assert.Equal(t, []string{"2024-04", "2024-07", "2024-10", "2025-01"}, tickLabels(tlc, from, from.AddDate(1, 0, 0), 6))
+
+	tlc.Labels = ChartLabels{DateFormat: "1月2日", LowerLimit: "下限"}
+	assert.Equal(t, []string{"1月3日", "1月4日", "1月5日"}, tickLabels(tlc, from, from.Add(72*time.Hour), 6))
+	assert.Equal(t, "下限", tlc.labels().LowerLimit)
+	assert.Equal(t, "Upper Limit", tlc.labels().UpperLimit)
+
+	// 未指定時區時固定用 UTC，不受 time.Local 影響
+	local := time.Local
+	time.Local = loc
+	defer func() { time.Local = local }()
+	tlc = &TimeLineChart{}
+	assert.Equal(t, time.UTC, tlc.location())
+	assert.Equal(t, time.UTC, (&HeatMap{}).location())
+	assert.Equal(t, []string{"18:00", "20:00", "22:00"}, tickLabels(tlc, from, from.Add(6*time.Hour), 6))
+}
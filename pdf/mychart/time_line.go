@@ -1,8 +1,10 @@
 package mychart
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"time"
 
@@ -19,37 +21,126 @@ type TimeLineChart struct {
 	UpperValue    float64
 	LowerValue    float64
 	YAxisName     string
-	NoUpperLower  bool
-	ShowCI        bool
-	DP            uint // 小數位數
+	// 第二 Y 軸，TimeLine 設定 Secondary 時使用
+	SecondaryYAxisName string
+	SecondaryDP        uint
+	NoUpperLower       bool
+	ShowCI             bool
+	DP                 uint // 小數位數
+	// 預期的取樣間隔，設定時相鄰資料超過 1.5 倍間隔視為中斷，線條斷開並以 GapColor 標示
+	Interval time.Duration
+	GapColor style.Color
+	// 刻度及標籤使用的時區，nil 為 UTC，不隨執行環境變動
+	Location *time.Location
+	// 座標軸、上下限及圖例的文字
+	Labels ChartLabels
+	// 垂直標記、時間區間底色及水平數值帶
+	Markers []Marker
+	Ranges  []TimeRange
+	Bands   []ValueBand
+	// 資料點多於 MaxPoints 時的降取樣方式，使用時於圖表下方註明
+	Downsample Downsample
+	// 每條線最多的點數，0 為圖寬
+	MaxPoints int
+	// DownsampleAverage 的平均間隔，0 時依 MaxPoints 計算
+	AverageInterval time.Duration
 
 	Width  int
 	Height int
+	// 輸出格式，空字串為 PNG
+	Format Format
+	// PNG 的解析度倍數，例如 2 輸出兩倍像素，0 為 1
+	Scale float64
+	// 字型快取，nil 時使用 DefaultFontCache
+	Fonts *FontCache
+}
+
+// ErrNoData 沒有可畫的資料
+var ErrNoData = errors.New("mychart: no data")
+
+// timeLineState 一次 Draw 的狀態，Draw 不修改 TimeLineChart，可同時由多個 goroutine 呼叫
+type timeLineState struct {
+	*TimeLineChart
+	width  int
+	height int
+	dp     uint
 
 	max float64
 	min float64
+	// 第二 Y 軸的範圍
+	max2 float64
+	min2 float64
+	// 降取樣前後的資料點數
+	sampledFrom int
+	sampledTo   int
+}
+
+func (tlc *TimeLineChart) newState() *timeLineState {
+	s := &timeLineState{
+		TimeLineChart: tlc,
+		width:         tlc.Width,
+		height:        tlc.Height,
+		dp:            tlc.DP,
+		max:           math.Inf(-1),
+		min:           math.Inf(1),
+		max2:          math.Inf(-1),
+		min2:          math.Inf(1),
+	}
+	if s.dp == 0 {
+		s.dp = 1
+	}
+	if s.width == 0 {
+		s.width = 1000
+	}
+	if s.height == 0 {
+		s.height = 600
+	}
+	return s
 }
 
 type TimeLine struct {
 	Name  string
 	Data  map[int64]float64
 	Color style.Color
+
+	// 使用第二 Y 軸
+	Secondary bool
+	// 線寬，0 為預設
+	Width float64
+	// 虛線樣式，例如 []float64{5, 5}
+	Dash []float64
+	// 資料點的圓點直徑，0 為不畫
+	Marker float64
+	// 階梯線，值維持到下一筆資料，適用於開關狀態
+	Step bool
+	// 線下方以半透明顏色填滿
+	Fill bool
+	// 不顯示於圖例
+	HideInLegend bool
 }
 
-func (tl *TimeLine) toDrawColor() drawing.Color {
+// toDrawColor 未設定顏色時使用 fallback
+func (tl *TimeLine) toDrawColor(fallback style.Color) drawing.Color {
+	c := tl.Color
+	if c == (style.Color{}) {
+		c = fallback
+	}
 	return drawing.Color{
-		R: tl.Color.R,
-		G: tl.Color.G,
-		B: tl.Color.B,
-		A: tl.Color.A,
+		R: c.R,
+		G: c.G,
+		B: c.B,
+		A: uint8(c.Opacity() * 255),
 	}
 }
 
-func (tlc *TimeLineChart) getTimeSeries() []chart.Series {
+func (tlc *timeLineState) getTimeSeries() []chart.Series {
 	timeLen := len(tlc.TimestampList)
 	if timeLen <= 1 {
 		return nil
 	}
+	if tlc.Interval > 0 {
+		return tlc.getSegmentSeries()
+	}
 	timeAry := make([]time.Time, timeLen)
 	for i := 0; i < timeLen; i++ {
 		timeAry[i] = time.Unix(tlc.TimestampList[i], 0)
@@ -57,47 +148,24 @@ func (tlc *TimeLineChart) getTimeSeries() []chart.Series {
 
 	dataLen := len(tlc.TimeData)
 	timeSeries := make([]chart.Series, dataLen)
-	var value float64
-	var ok bool
-	var timestamp int64
+	palette := style.Palette(dataLen)
 	for i := 0; i < dataLen; i++ {
+		line := &tlc.TimeData[i]
 		yValeAry := make([]float64, timeLen)
 		for j := 0; j < timeLen; j++ {
-			timestamp = tlc.TimestampList[j]
-			value, ok = tlc.TimeData[i].Data[timestamp]
-			if ok {
-				yValeAry[j] = value
-			} else {
-				yValeAry[j] = 0
-			}
-			if i == 0 && j == 0 {
-				tlc.max = value
-				tlc.min = value
-			} else {
-				if value > tlc.max {
-					tlc.max = value
-				}
-				if value < tlc.min {
-					tlc.min = value
-				}
-			}
-		}
-		timeSeries[i] = chart.TimeSeries{
-			Name:    tlc.TimeData[i].Name,
-			XValues: timeAry,
-			YValues: yValeAry,
-			Style: chart.Style{
-				Show:        true,
-				StrokeColor: tlc.TimeData[i].toDrawColor(),
-			},
+			// 沒有資料時為 0
+			yValeAry[j] = line.Data[tlc.TimestampList[j]]
+			tlc.track(line.Secondary, yValeAry[j])
 		}
+		xs, ys := tlc.reduce(timeAry, yValeAry, line.Secondary)
+		timeSeries[i] = line.series(xs, ys, palette[i], true)
 	}
 	return timeSeries
 }
 
 func (tlc *TimeLineChart) getUpperLowerSeries() []chart.Series {
 	minSeries := &chart.MinSeries{
-		Name: "Lower Limit",
+		Name: tlc.labels().LowerLimit,
 		Style: chart.Style{
 			Show:            true,
 			StrokeColor:     chart.ColorAlternateGray,
@@ -107,7 +175,7 @@ func (tlc *TimeLineChart) getUpperLowerSeries() []chart.Series {
 	}
 	maxSeries := &chart.MinSeries{
 
-		Name: "Upper Limit",
+		Name: tlc.labels().UpperLimit,
 		Style: chart.Style{
 			Show:            true,
 			StrokeColor:     chart.ColorAlternateGray,
@@ -125,7 +193,7 @@ func (tlc *TimeLineChart) getUpperLowerSeries() []chart.Series {
 
 func (tlc *TimeLineChart) getCISeries() []chart.Series {
 	minSeries := &chart.MinSeries{
-		Name: "-1.96D",
+		Name: tlc.labels().CILower,
 		Style: chart.Style{
 			Show:            true,
 			StrokeColor:     chart.ColorRed,
@@ -135,7 +203,7 @@ func (tlc *TimeLineChart) getCISeries() []chart.Series {
 	}
 	maxSeries := &chart.MinSeries{
 
-		Name: "1.96D",
+		Name: tlc.labels().CIUpper,
 		Style: chart.Style{
 			Show:            true,
 			StrokeColor:     chart.ColorRed,
@@ -151,30 +219,47 @@ func (tlc *TimeLineChart) getCISeries() []chart.Series {
 	}
 }
 
-func readFont(fontfile string) *truetype.Font {
+func readFont(fontfile string) (*truetype.Font, error) {
 	// 讀字體
 	fontBytes, err := os.ReadFile(fontfile)
 	if err != nil {
-		fmt.Println(err.Error())
-		return nil
+		return nil, fmt.Errorf("font %s: %w", fontfile, err)
 	}
 	font, err := truetype.Parse(fontBytes)
 	if err != nil {
-		fmt.Println(err.Error())
-		return nil
+		return nil, fmt.Errorf("font %s: %w", fontfile, err)
+	}
+	return font, nil
+}
+
+func (tlc *TimeLineChart) fonts() *FontCache {
+	if tlc.Fonts != nil {
+		return tlc.Fonts
 	}
-	return font
+	return DefaultFontCache
+}
+
+// Draw 輸出圖表，沒有資料時回傳 ErrNoData
+func (tlc *TimeLineChart) Draw(fontfile string, ioWriter io.Writer) error {
+	return tlc.newState().draw(fontfile, ioWriter)
 }
 
-func (tlc *TimeLineChart) Draw(fontfile string, ioWriter io.Writer) {
+func (tlc *timeLineState) draw(fontfile string, ioWriter io.Writer) error {
 	timeSeries := tlc.getTimeSeries()
-	if timeSeries == nil {
-		return
+	if len(timeSeries) == 0 {
+		return ErrNoData
 	}
-	if tlc.min == tlc.max {
-		tlc.min--
-		tlc.max++
+	font, err := tlc.fonts().Font(fontfile)
+	if err != nil {
+		return err
 	}
+	provider, err := tlc.rendererProvider(fontfile)
+	if err != nil {
+		return err
+	}
+	tlc.min, tlc.max = axisRange(tlc.min, tlc.max)
+	tlc.min2, tlc.max2 = axisRange(tlc.min2, tlc.max2)
+	tlc.includeBands()
 	if !tlc.NoUpperLower {
 		timeSeries = append(timeSeries, tlc.getUpperLowerSeries()...)
 		if tlc.LowerValue < tlc.min {
@@ -192,32 +277,21 @@ func (tlc *TimeLineChart) Draw(fontfile string, ioWriter io.Writer) {
 			tlc.max = tlc.UpperValue
 		}
 	}
-	dateFormat := "01-02 15:04"
-
-	if tlc.DP == 0 {
-		tlc.DP = 1
-	}
-	if tlc.Width == 0 {
-		tlc.Width = 1000
-	}
-	if tlc.Height == 0 {
-		tlc.Height = 600
-	}
-	if len(timeSeries) == 0 {
-		return
-	}
+	labels := tlc.labels()
+	loc := tlc.location()
+	dateFormat := labels.DateTimeFormat
 
 	//ns := chart.StyleShow()
 	graph := chart.Chart{
-		Width:  tlc.Width,
-		Height: tlc.Height,
+		Width:  tlc.width,
+		Height: tlc.height,
 		Background: chart.Style{
 			Padding: chart.Box{
 				Top: 50,
 			},
 		},
 		XAxis: chart.XAxis{
-			Name: "Time",
+			Name: labels.Time,
 			//NameStyle: ns,
 			Style: chart.Style{
 				Show:     true,
@@ -226,13 +300,13 @@ func (tlc *TimeLineChart) Draw(fontfile string, ioWriter io.Writer) {
 			},
 			ValueFormatter: func(v interface{}) string {
 				if typed, isTyped := v.(time.Time); isTyped {
-					return typed.Format(dateFormat)
+					return typed.In(loc).Format(dateFormat)
 				}
 				if typed, isTyped := v.(int64); isTyped {
-					return time.Unix(0, typed).Format(dateFormat)
+					return time.Unix(0, typed).In(loc).Format(dateFormat)
 				}
 				if typed, isTyped := v.(float64); isTyped {
-					return time.Unix(0, int64(typed)).Format(dateFormat)
+					return time.Unix(0, int64(typed)).In(loc).Format(dateFormat)
 				}
 				return ""
 			},
@@ -242,12 +316,11 @@ func (tlc *TimeLineChart) Draw(fontfile string, ioWriter io.Writer) {
 			//NameStyle: ns,
 			Style: chart.Style{
 				Show: true,
-				Font: readFont(fontfile),
+				Font: font,
 			},
 			ValueFormatter: func(v interface{}) string {
 				if typed, isTyped := v.(float64); isTyped {
-					format := fmt.Sprintf("%%.%df", tlc.DP)
-					return fmt.Sprintf(format, typed)
+					return fmt.Sprintf("%.*f", tlc.dp, typed)
 				}
 				return ""
 			},
@@ -262,28 +335,89 @@ func (tlc *TimeLineChart) Draw(fontfile string, ioWriter io.Writer) {
 		},
 		Series: timeSeries,
 	}
+	// 色塊及標記放在最前面，畫在資料線下方
+	if tlc.hasAnnotations() {
+		graph.Series = append(tlc.annotations(), graph.Series...)
+	}
+	if tlc.Interval > 0 {
+		graph.Series = append([]chart.Series{tlc.gapBands()}, graph.Series...)
+	}
 
+	if tlc.hasSecondary() {
+		dp := tlc.SecondaryDP
+		if dp == 0 {
+			dp = 1
+		}
+		graph.YAxisSecondary = chart.YAxis{
+			Name: tlc.SecondaryYAxisName,
+			Style: chart.Style{
+				Show: true,
+				Font: font,
+			},
+			ValueFormatter: func(v interface{}) string {
+				if typed, isTyped := v.(float64); isTyped {
+					return fmt.Sprintf("%.*f", dp, typed)
+				}
+				return ""
+			},
+			Range: &chart.ContinuousRange{
+				Min: tlc.min2,
+				Max: tlc.max2,
+			},
+		}
+	}
 	graph.Elements = []chart.Renderable{chart.LegendThin(&graph, chart.Style{
 		FontSize: 16,
 	})}
-	graph.Font = readFont(fontfile)
-	err := graph.Render(chart.PNG, ioWriter)
-	if err != nil {
-		panic(err)
+	graph.XAxis.Ticks = tlc.timeTicks(
+		time.Unix(tlc.TimestampList[0], 0),
+		time.Unix(tlc.TimestampList[len(tlc.TimestampList)-1], 0),
+		tlc.width/90,
+	)
+	if tlc.sampledTo < tlc.sampledFrom {
+		graph.Background.Padding.Bottom = 25
+		graph.Elements = append(graph.Elements, tlc.footer())
+	}
+	if tlc.Interval > 0 {
+		graph.XAxis.Range = &chart.ContinuousRange{
+			Min: float64(time.Unix(tlc.TimestampList[0], 0).UnixNano()),
+			Max: float64(time.Unix(tlc.TimestampList[len(tlc.TimestampList)-1], 0).UnixNano()),
+		}
 	}
+	graph.Font = font
+	return graph.Render(provider, ioWriter)
+}
+
+// limitTimestamps 上下限是水平線，降取樣時只需頭尾兩點
+func (tlc *TimeLineChart) limitTimestamps() []int64 {
+	if tlc.Downsample == DownsampleNone {
+		return tlc.TimestampList
+	}
+	return []int64{tlc.TimestampList[0], tlc.TimestampList[len(tlc.TimestampList)-1]}
+}
 
+// rendererProvider 原尺寸 PNG 使用 go-chart 的點陣輸出，其餘使用向量輸出
+func (tlc *TimeLineChart) rendererProvider(fontfile string) (chart.RendererProvider, error) {
+	if (tlc.Format == "" || tlc.Format == FormatPNG) && (tlc.Scale == 0 || tlc.Scale == 1) {
+		return chart.PNG, nil
+	}
+	pf, err := tlc.fonts().plotFont(fontfile)
+	if err != nil {
+		return nil, err
+	}
+	return vectorRenderer(tlc.Format, tlc.Scale, pf), nil
 }
 
 func (tlc *TimeLineChart) getLowerSeries() upperLowerSeries {
 	return upperLowerSeries{
-		TimestampList: tlc.TimestampList,
+		TimestampList: tlc.limitTimestamps(),
 		Value:         tlc.LowerValue,
 	}
 }
 
 func (tlc *TimeLineChart) getUpperSeries() upperLowerSeries {
 	return upperLowerSeries{
-		TimestampList: tlc.TimestampList,
+		TimestampList: tlc.limitTimestamps(),
 		Value:         tlc.UpperValue,
 	}
 }
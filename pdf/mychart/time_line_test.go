@@ -0,0 +1,56 @@
+package mychart
+
+import (
+	"bytes"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func Test_TimeLineDrawReusable(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	line := TimeLine{Name: "Temp", Data: map[int64]float64{}}
+	tlc := &TimeLineChart{UpperValue: 8, LowerValue: 2, Location: time.UTC, Fonts: NewFontCache()}
+	for i := int64(0); i < 12; i++ {
+		ts := start + i*600
+		tlc.TimestampList = append(tlc.TimestampList, ts)
+		line.Data[ts] = float64(i)
+	}
+	tlc.TimeData = []TimeLine{line}
+	fontFile := t.TempDir() + "/goregular.ttf"
+	assert.NoError(t, os.WriteFile(fontFile, goregular.TTF, 0o644))
+
+	var first bytes.Buffer
+	assert.NoError(t, tlc.Draw(fontFile, &first))
+	// 設定不被 Draw 修改
+	assert.Equal(t, 0, tlc.Width)
+	assert.Equal(t, uint(0), tlc.DP)
+
+	var wg sync.WaitGroup
+	outputs := make([][]byte, 4)
+	for i := range outputs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var buf bytes.Buffer
+			assert.NoError(t, tlc.Draw(fontFile, &buf))
+			outputs[i] = buf.Bytes()
+		}(i)
+	}
+	wg.Wait()
+	for _, o := range outputs {
+		assert.Equal(t, first.Bytes(), o)
+	}
+
+	f1, err := tlc.Fonts.Font(fontFile)
+	assert.NoError(t, err)
+	f2, _ := tlc.Fonts.Font(fontFile)
+	assert.Same(t, f1, f2)
+
+	assert.Error(t, tlc.Draw(t.TempDir()+"/missing.ttf", &first))
+	assert.ErrorIs(t, (&TimeLineChart{}).Draw(fontFile, &first), ErrNoData)
+}
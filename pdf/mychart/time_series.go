@@ -0,0 +1,87 @@
+package mychart
+
+import (
+	"math"
+	"time"
+
+	"github.com/94peter/export/pdf/style"
+	"github.com/wcharczuk/go-chart"
+)
+
+// track 更新 Y 軸範圍
+func (tlc *timeLineState) track(secondary bool, v float64) {
+	if secondary {
+		tlc.max2, tlc.min2 = math.Max(tlc.max2, v), math.Min(tlc.min2, v)
+		return
+	}
+	tlc.max, tlc.min = math.Max(tlc.max, v), math.Min(tlc.min, v)
+}
+
+// axisRange 沒有資料時為 0-1，最大最小相同時上下各留 1
+func axisRange(min, max float64) (float64, float64) {
+	if math.IsInf(min, 0) || math.IsInf(max, 0) {
+		return 0, 1
+	}
+	if min == max {
+		return min - 1, max + 1
+	}
+	return min, max
+}
+
+func (tlc *TimeLineChart) hasSecondary() bool {
+	for i := range tlc.TimeData {
+		if tlc.TimeData[i].Secondary {
+			return true
+		}
+	}
+	return false
+}
+
+// stepValues 在每筆資料前插入維持前一個值的點
+func stepValues(xs []time.Time, ys []float64) ([]time.Time, []float64) {
+	if len(xs) < 2 {
+		return xs, ys
+	}
+	sx := make([]time.Time, 0, len(xs)*2-1)
+	sy := make([]float64, 0, len(ys)*2-1)
+	for i := range xs {
+		if i > 0 {
+			sx = append(sx, xs[i])
+			sy = append(sy, ys[i-1])
+		}
+		sx = append(sx, xs[i])
+		sy = append(sy, ys[i])
+	}
+	return sx, sy
+}
+
+// series 以線的樣式建立 TimeSeries，legend 為 false 或設定 HideInLegend 時不顯示於圖例
+func (tl *TimeLine) series(xs []time.Time, ys []float64, fallback style.Color, legend bool) chart.TimeSeries {
+	color := tl.toDrawColor(fallback)
+	s := chart.TimeSeries{
+		Style: chart.Style{
+			Show:            true,
+			StrokeColor:     color,
+			StrokeWidth:     tl.Width,
+			StrokeDashArray: append([]float64(nil), tl.Dash...),
+		},
+	}
+	if legend && !tl.HideInLegend {
+		s.Name = tl.Name
+	}
+	if tl.Secondary {
+		s.YAxis = chart.YAxisSecondary
+	}
+	if tl.Marker > 0 {
+		s.Style.DotWidth = tl.Marker / 2
+		s.Style.DotColor = color
+	}
+	if tl.Fill {
+		s.Style.FillColor = color.WithAlpha(uint8(float64(color.A) * 0.3))
+	}
+	if tl.Step {
+		xs, ys = stepValues(xs, ys)
+	}
+	s.XValues, s.YValues = xs, ys
+	return s
+}
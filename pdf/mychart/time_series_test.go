@@ -0,0 +1,111 @@
+package mychart
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wcharczuk/go-chart"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func Test_TimeLineSecondary(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	temp := TimeLine{Name: "Temp", Data: map[int64]float64{}, Marker: 4, Fill: true}
+	door := TimeLine{Name: "Door", Data: map[int64]float64{}, Secondary: true, Step: true, Dash: []float64{4, 2}}
+	hidden := TimeLine{Name: "Hidden", Data: map[int64]float64{}, HideInLegend: true}
+	tlc := &TimeLineChart{
+		NoUpperLower:       true,
+		SecondaryYAxisName: "Open",
+		Width:              600,
+		Height:             300,
+	}
+	for i := int64(0); i < 6; i++ {
+		ts := start + i*600
+		tlc.TimestampList = append(tlc.TimestampList, ts)
+		temp.Data[ts] = 2 + float64(i)
+		door.Data[ts] = float64(i % 2)
+		hidden.Data[ts] = 3
+	}
+	tlc.TimeData = []TimeLine{temp, door, hidden}
+
+	s := tlc.newState()
+	series := s.getTimeSeries()
+	assert.Len(t, series, 3)
+	assert.Equal(t, chart.YAxisPrimary, series[0].GetYAxis())
+	assert.Equal(t, chart.YAxisSecondary, series[1].GetYAxis())
+	assert.Equal(t, 11, series[1].(chart.TimeSeries).Len())
+	assert.Equal(t, "", series[2].GetName())
+	assert.Equal(t, 2.0, s.min)
+	assert.Equal(t, 7.0, s.max)
+	assert.Equal(t, 0.0, s.min2)
+	assert.Equal(t, 1.0, s.max2)
+
+	fontFile := t.TempDir() + "/goregular.ttf"
+	assert.NoError(t, os.WriteFile(fontFile, goregular.TTF, 0o644))
+	var buf bytes.Buffer
+	assert.NoError(t, tlc.Draw(fontFile, &buf))
+	assert.NotZero(t, buf.Len())
+}
+
+func Test_TimeLineAnnotations(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	line := TimeLine{Name: "Temp", Data: map[int64]float64{}}
+	tlc := &TimeLineChart{NoUpperLower: true, Width: 600, Height: 300}
+	for i := int64(0); i < 6; i++ {
+		ts := start + i*600
+		tlc.TimestampList = append(tlc.TimestampList, ts)
+		line.Data[ts] = float64(i)
+	}
+	tlc.TimeData = []TimeLine{line}
+	tlc.Markers = []Marker{{Time: start + 600, Label: "Door opened"}}
+	tlc.Ranges = []TimeRange{{Start: start + 1200, End: start + 1800, Label: "Defrost"}}
+	tlc.Bands = []ValueBand{{Min: 8, Max: math.Inf(1), Label: "Alarm"}}
+
+	s := tlc.newState()
+	s.getTimeSeries()
+	s.includeBands()
+	assert.Equal(t, 0.0, s.min)
+	assert.Equal(t, 8.0, s.max)
+	series := tlc.annotations()
+	assert.Len(t, series, 2)
+	for _, s := range series {
+		assert.Equal(t, "", s.GetName())
+	}
+
+	fontFile := t.TempDir() + "/goregular.ttf"
+	assert.NoError(t, os.WriteFile(fontFile, goregular.TTF, 0o644))
+	var buf bytes.Buffer
+	assert.NoError(t, tlc.Draw(fontFile, &buf))
+	assert.NotZero(t, buf.Len())
+}
+
+func Test_TimeLineAnnotationClip(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	tlc := &TimeLineChart{
+		Markers: []Marker{{Time: start - 600, Label: "Before"}, {Time: start + 600, Label: "In"}, {Time: start + 4000, Label: "After"}},
+		Ranges:  []TimeRange{{Start: start - 1200, End: start + 1800, Label: "Defrost"}, {Start: start + 3600, End: start + 4200, Label: "Out"}},
+	}
+	series := tlc.annotations()
+	r, err := chart.SVG(600, 300)
+	assert.NoError(t, err)
+	cb := chart.Box{Top: 10, Left: 50, Right: 550, Bottom: 250}
+	xr := &chart.ContinuousRange{Min: float64(time.Unix(start, 0).UnixNano()), Max: float64(time.Unix(start+3000, 0).UnixNano()), Domain: cb.Width()}
+	yr := &chart.ContinuousRange{Min: 0, Max: 10, Domain: cb.Height()}
+	series[len(series)-1].Render(r, cb, xr, yr, chart.Style{})
+	var buf bytes.Buffer
+	assert.NoError(t, r.Save(&buf))
+	out := buf.String()
+
+	// 超出時間軸的區間裁到繪圖區，整段在外的區間及標記不畫
+	assert.Contains(t, out, "M 50 10\nL 350 10")
+	assert.Contains(t, out, ">Defrost<")
+	assert.Contains(t, out, ">In<")
+	assert.NotContains(t, out, ">Before<")
+	assert.NotContains(t, out, ">After<")
+	assert.NotContains(t, out, ">Out<")
+	assert.NotContains(t, out, "-150")
+}
@@ -0,0 +1,202 @@
+package mychart
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/golang/freetype/truetype"
+	"github.com/wcharczuk/go-chart"
+	"github.com/wcharczuk/go-chart/drawing"
+	"gonum.org/v1/plot/font"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/vgimg"
+	"gonum.org/v1/plot/vg/vgpdf"
+	"gonum.org/v1/plot/vg/vgsvg"
+)
+
+// Format 圖表輸出格式
+type Format string
+
+const (
+	FormatPNG Format = "png"
+	FormatSVG Format = "svg"
+	// 向量 PDF，字型內嵌
+	FormatPDF Format = "pdf"
+)
+
+// newCanvas 建立 width*height 像素的畫布，scale 只影響 PNG 的解析度
+func newCanvas(format Format, width, height int, scale float64) (vg.CanvasWriterTo, error) {
+	w, h := pixels(width), pixels(height)
+	switch format {
+	case FormatPNG, "":
+		if scale <= 0 {
+			scale = 1
+		}
+		dpi := int(math.Round(vgimg.DefaultDPI * scale))
+		return vgimg.PngCanvas{Canvas: vgimg.NewWith(vgimg.UseWH(w, h), vgimg.UseDPI(dpi))}, nil
+	case FormatSVG:
+		return vgsvg.New(w, h), nil
+	case FormatPDF:
+		c := vgpdf.New(w, h)
+		c.EmbedFonts(true)
+		return c, nil
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+// length 像素換算為 vg 長度
+func length(px float64) vg.Length {
+	return vg.Length(px) * vg.Inch / vgimg.DefaultDPI
+}
+
+// toPixels vg 長度換算為像素
+func toPixels(l vg.Length) int {
+	return int(math.Ceil(float64(l / (vg.Inch / vgimg.DefaultDPI))))
+}
+
+// vectorRenderer 讓 go-chart 畫在 gonum 的 vg 畫布上，輸出 SVG、向量 PDF 或高解析度 PNG
+func vectorRenderer(format Format, scale float64, pf *plotFont) chart.RendererProvider {
+	return func(width, height int) (chart.Renderer, error) {
+		c, err := newCanvas(format, width, height, scale)
+		if err != nil {
+			return nil, err
+		}
+		return &vgRenderer{canvas: c, font: pf, height: height, dpi: chart.DefaultDPI}, nil
+	}
+}
+
+// vgRenderer 實作 chart.Renderer，座標由 go-chart 的左上原點轉為 vg 的左下原點
+type vgRenderer struct {
+	canvas vg.CanvasWriterTo
+	font   *plotFont
+	height int
+	dpi    float64
+	path   vg.Path
+	style  chart.Style
+	// 文字旋轉角度，nil 為不旋轉
+	rotation *float64
+}
+
+func (r *vgRenderer) point(x, y float64) vg.Point {
+	return vg.Point{X: length(x), Y: length(float64(r.height) - y)}
+}
+
+func (r *vgRenderer) ResetStyle() {
+	r.style = chart.Style{}
+	r.ClearTextRotation()
+}
+
+func (r *vgRenderer) GetDPI() float64        { return r.dpi }
+func (r *vgRenderer) SetDPI(dpi float64)     { r.dpi = dpi }
+func (r *vgRenderer) SetFont(*truetype.Font) {}
+
+func (r *vgRenderer) SetStrokeColor(c drawing.Color)    { r.style.StrokeColor = c }
+func (r *vgRenderer) SetFillColor(c drawing.Color)      { r.style.FillColor = c }
+func (r *vgRenderer) SetStrokeWidth(width float64)      { r.style.StrokeWidth = width }
+func (r *vgRenderer) SetStrokeDashArray(dash []float64) { r.style.StrokeDashArray = dash }
+func (r *vgRenderer) SetFontColor(c drawing.Color)      { r.style.FontColor = c }
+func (r *vgRenderer) SetFontSize(size float64)          { r.style.FontSize = size }
+
+func (r *vgRenderer) MoveTo(x, y int) {
+	r.path.Move(r.point(float64(x), float64(y)))
+}
+
+func (r *vgRenderer) LineTo(x, y int) {
+	r.path.Line(r.point(float64(x), float64(y)))
+}
+
+func (r *vgRenderer) QuadCurveTo(cx, cy, x, y int) {
+	r.path.QuadTo(r.point(float64(cx), float64(cy)), r.point(float64(x), float64(y)))
+}
+
+// ArcTo go-chart 的角度以順時針為正，vg 以逆時針為正
+func (r *vgRenderer) ArcTo(cx, cy int, rx, ry, startAngle, delta float64) {
+	r.path.Arc(r.point(float64(cx), float64(cy)), length(rx), -startAngle, -delta)
+}
+
+func (r *vgRenderer) Close() {
+	r.path.Close()
+}
+
+func (r *vgRenderer) Circle(radius float64, x, y int) {
+	r.path.Move(r.point(float64(x)+radius, float64(y)))
+	r.path.Arc(r.point(float64(x), float64(y)), length(radius), 0, 2*math.Pi)
+	r.path.Close()
+}
+
+func (r *vgRenderer) Stroke() {
+	r.stroke()
+	r.path = nil
+}
+
+func (r *vgRenderer) Fill() {
+	r.fill()
+	r.path = nil
+}
+
+func (r *vgRenderer) FillStroke() {
+	r.fill()
+	r.stroke()
+	r.path = nil
+}
+
+func (r *vgRenderer) fill() {
+	if r.style.FillColor.A == 0 || len(r.path) == 0 {
+		return
+	}
+	r.canvas.SetColor(r.style.FillColor)
+	r.canvas.Fill(r.path)
+}
+
+func (r *vgRenderer) stroke() {
+	if r.style.StrokeColor.A == 0 || r.style.StrokeWidth <= 0 || len(r.path) == 0 {
+		return
+	}
+	dash := make([]vg.Length, len(r.style.StrokeDashArray))
+	for i, d := range r.style.StrokeDashArray {
+		dash[i] = length(d)
+	}
+	r.canvas.SetColor(r.style.StrokeColor)
+	r.canvas.SetLineWidth(length(r.style.StrokeWidth))
+	r.canvas.SetLineDash(dash, 0)
+	r.canvas.Stroke(r.path)
+}
+
+// face 字型大小為 DPI 下的點數，與 go-chart 的 PNG 輸出相同
+func (r *vgRenderer) face() font.Face {
+	return r.font.face(length(r.style.FontSize * r.dpi / 72))
+}
+
+func (r *vgRenderer) Text(body string, x, y int) {
+	r.canvas.Push()
+	defer r.canvas.Pop()
+	r.canvas.SetColor(r.style.FontColor)
+	r.canvas.Translate(r.point(float64(x), float64(y)))
+	if r.rotation != nil {
+		r.canvas.Rotate(-*r.rotation)
+	}
+	r.canvas.FillString(r.face(), vg.Point{}, body)
+}
+
+func (r *vgRenderer) MeasureText(body string) chart.Box {
+	face := r.face()
+	box := chart.Box{Right: toPixels(face.Width(body)), Bottom: toPixels(face.Extents().Ascent)}
+	if r.rotation == nil {
+		return box
+	}
+	return box.Corners().Rotate(*r.rotation * 180 / math.Pi).Box()
+}
+
+func (r *vgRenderer) SetTextRotation(radians float64) {
+	r.rotation = &radians
+}
+
+func (r *vgRenderer) ClearTextRotation() {
+	r.rotation = nil
+}
+
+func (r *vgRenderer) Save(w io.Writer) error {
+	_, err := r.canvas.WriteTo(w)
+	return err
+}
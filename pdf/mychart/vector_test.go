@@ -0,0 +1,49 @@
+package mychart
+
+import (
+	"bytes"
+	"image/png"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func Test_TimeLineFormat(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	fontFile := t.TempDir() + "/goregular.ttf"
+	assert.NoError(t, os.WriteFile(fontFile, goregular.TTF, 0o644))
+	draw := func(format Format, scale float64) ([]byte, error) {
+		line := TimeLine{Name: "Temp", Data: map[int64]float64{}}
+		tlc := &TimeLineChart{UpperValue: 8, LowerValue: 2, Width: 600, Height: 300, Format: format, Scale: scale}
+		for i := int64(0); i < 12; i++ {
+			ts := start + i*600
+			tlc.TimestampList = append(tlc.TimestampList, ts)
+			line.Data[ts] = float64(i)
+		}
+		tlc.TimeData = []TimeLine{line}
+		var buf bytes.Buffer
+		err := tlc.Draw(fontFile, &buf)
+		return buf.Bytes(), err
+	}
+	output := func(format Format, scale float64) []byte {
+		b, err := draw(format, scale)
+		assert.NoError(t, err)
+		return b
+	}
+
+	img, err := png.DecodeConfig(bytes.NewReader(output("", 0)))
+	assert.NoError(t, err)
+	assert.Equal(t, 600, img.Width)
+	img, err = png.DecodeConfig(bytes.NewReader(output(FormatPNG, 2)))
+	assert.NoError(t, err)
+	assert.Equal(t, 1200, img.Width)
+	assert.Equal(t, 600, img.Height)
+
+	assert.Contains(t, string(output(FormatSVG, 0)), "<svg")
+	assert.True(t, bytes.HasPrefix(output(FormatPDF, 0), []byte("%PDF")))
+	_, err = draw("gif", 0)
+	assert.Error(t, err)
+}
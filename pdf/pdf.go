@@ -14,6 +14,7 @@ type pdf struct {
 	width, height                                    float64
 	leftMargin, topMargin, rightMargin, bottomMargin float64
 	page                                             uint8
+	fonts                                            map[string]bool
 }
 
 func GetA4PDF(fontMap map[string]string, leftMargin, rightMargin, topMargin, bottomMargin float64) pdf {
@@ -22,11 +23,13 @@ func GetA4PDF(fontMap map[string]string, leftMargin, rightMargin, topMargin, bot
 	pageSize := *gopdf.PageSizeA4
 	gpdf.Start(gopdf.Config{PageSize: pageSize}) //595.28, 841.89 = A4
 	var err error
+	fonts := make(map[string]bool, len(fontMap))
 	for key, value := range fontMap {
 		err = gpdf.AddTTFFont(key, value)
 		if err != nil {
 			panic(err)
 		}
+		fonts[key] = true
 	}
 	gpdf.SetLeftMargin(leftMargin)
 	gpdf.SetTopMargin(topMargin)
@@ -38,6 +41,7 @@ func GetA4PDF(fontMap map[string]string, leftMargin, rightMargin, topMargin, bot
 		rightMargin:  rightMargin,
 		topMargin:    topMargin,
 		bottomMargin: bottomMargin,
+		fonts:        fonts,
 	}
 }
 
@@ -68,6 +72,10 @@ func (p *pdf) GetWidth() float64 {
 	return p.width - p.leftMargin - p.rightMargin
 }
 
+func (p *pdf) HasFont(name string) bool {
+	return p.fonts[name]
+}
+
 func (p *pdf) Line(width float64) {
 	pdf := p.myPDF
 	pdf.SetLineWidth(width)
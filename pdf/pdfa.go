@@ -0,0 +1,85 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"time"
+)
+
+var ErrPDFA = errors.New("not allowed in PDF/A-2b")
+
+// WithPDFA 以 PDF/A-2b 封存格式輸出，使用不允許的功能時 Write 會回傳 ErrPDFA
+func WithPDFA() Option {
+	return func(p *pdfv2) {
+		p.pdfa = true
+	}
+}
+
+func (p *pdfv2) pdfaViolation(format string, a ...interface{}) {
+	err := fmt.Errorf("%w: %s", ErrPDFA, fmt.Sprintf(format, a...))
+	for _, e := range p.pdfaErrs {
+		if e.Error() == err.Error() {
+			return
+		}
+	}
+	p.pdfaErrs = append(p.pdfaErrs, err)
+}
+
+func (p *pdfv2) checkPDFA() error {
+	if p.protection != nil {
+		p.pdfaViolation("encryption")
+	}
+	if err := errors.Join(p.pdfaErrs...); err != nil {
+		return err
+	}
+	if p.metadata == nil {
+		p.metadata = &Metadata{}
+	}
+	return nil
+}
+
+// pdfaImage 拒絕含透明的圖片，不透明的 RGBA PNG 重新編碼為 RGB，避免產生 SMask
+func (p *pdfv2) pdfaImage(data []byte) ([]byte, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if format != "png" {
+		return data, nil
+	}
+	if o, ok := img.(interface{ Opaque() bool }); ok && !o.Opaque() {
+		return nil, fmt.Errorf("%w: image with transparency", ErrPDFA)
+	}
+	var buf bytes.Buffer
+	if err = png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeOutputIntent 加入 sRGB OutputIntent 及文件識別碼
+func (p *pdfv2) writeOutputIntent(u *pdfUpdate) error {
+	rootID, ok := u.trailerRef("Root")
+	if !ok {
+		return fmt.Errorf("%w: trailer without /Root", ErrMalformedPDF)
+	}
+	catalog, err := u.dict(rootID)
+	if err != nil {
+		return err
+	}
+	iccID := u.alloc()
+	u.set(iccID, u.stream(iccID, "<<\n/N 3\n>>", sRGBProfile()))
+	intentID := u.alloc()
+	u.set(intentID, []byte(fmt.Sprintf(
+		"<<\n/Type /OutputIntent\n/S /GTS_PDFA1\n/OutputConditionIdentifier (sRGB IEC61966-2.1)\n/Info (sRGB IEC61966-2.1)\n/DestOutputProfile %s\n>>",
+		formatRef(iccID))))
+	u.set(rootID, []byte(dictSet(catalog, "OutputIntents", "["+formatRef(intentID)+"]")))
+
+	id := md5.Sum(append(u.src, time.Now().String()...))
+	u.setTrailer("ID", fmt.Sprintf("[<%X> <%X>]", id, id))
+	return nil
+}
@@ -0,0 +1,49 @@
+package pdf
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PDFA(t *testing.T) {
+	p := NewPDFv2(nil, 20, 20, 20, 20, WithPDFA())
+	p.AddDirectPage()
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	var imgBuf bytes.Buffer
+	assert.NoError(t, png.Encode(&imgBuf, img))
+	p.ImageReaderPosition(&imgBuf, 20, 20)
+
+	var buf bytes.Buffer
+	assert.NoError(t, p.Write(&buf))
+	out := buf.String()
+	assert.Contains(t, out, "<pdfaid:part>2</pdfaid:part>")
+	assert.Contains(t, out, "/S /GTS_PDFA1")
+	assert.Regexp(t, `/OutputIntents \[\d+ 0 R\]`, out)
+	assert.Regexp(t, `/ID \[<[0-9A-F]{32}> <[0-9A-F]{32}>\]`, out)
+	assert.NotContains(t, out, "/SMask")
+}
+
+func Test_PDFAViolation(t *testing.T) {
+	p := NewPDFv2(nil, 20, 20, 20, 20, WithPDFA(), WithProtection(Protection{UserPassword: "u"}))
+	assert.NoError(t, AddWatermark(p, Watermark{Text: "DRAFT", Opacity: 0.3}))
+	p.AddDirectPage()
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	img.Set(0, 0, color.NRGBA{R: 0xff, A: 0x80})
+	var imgBuf bytes.Buffer
+	assert.NoError(t, png.Encode(&imgBuf, img))
+	p.ImageReaderPosition(&imgBuf, 20, 20)
+
+	err := p.Write(&bytes.Buffer{})
+	assert.ErrorIs(t, err, ErrPDFA)
+	assert.ErrorContains(t, err, "encryption")
+	assert.ErrorContains(t, err, "watermark opacity")
+	assert.ErrorContains(t, err, "image with transparency")
+}
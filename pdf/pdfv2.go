@@ -1,6 +1,14 @@
+// Package pdf 以 gopdf 產生報表用的 PDF。
+//
+// PDF 介面只保留原有的方法，之後加入的功能 (字型查詢、圖片縮放、文字區塊、浮水印、文件資訊、條碼、事件表格及時間數值表格)
+// 各自定義擴充介面，避免其他實作 PDF 的型別因介面新增方法而無法編譯。NewPDFv2 回傳的 PDF 皆有實作，
+// 呼叫時使用同名的套件函式，p 未實作時回傳 errors.ErrUnsupported (HasFont 回傳 false)。
 package pdf
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"io"
 
 	"github.com/94peter/export/pdf/style"
@@ -12,6 +20,26 @@ type AddPagePipe interface {
 	After(p PDF)
 }
 
+// extension 將 p 轉為擴充介面 T，未實作時回傳以 feature 為前綴的 errors.ErrUnsupported
+func extension[T any](p PDF, feature string) (T, error) {
+	v, ok := p.(T)
+	if !ok {
+		return v, fmt.Errorf("%s: %w", feature, errors.ErrUnsupported)
+	}
+	return v, nil
+}
+
+// FontChecker 可查詢字型是否已載入
+type FontChecker interface {
+	HasFont(name string) bool
+}
+
+// HasFont 回傳是否已載入字型，p 未實作 FontChecker 時回傳 false
+func HasFont(p PDF, name string) bool {
+	fc, ok := p.(FontChecker)
+	return ok && fc.HasFont(name)
+}
+
 type PDF interface {
 	GetX() float64
 	GetY() float64
@@ -57,10 +85,27 @@ type PDF interface {
 	)
 }
 
-func NewPDFv2(fontMap map[string]string, left, right, top, bottom float64) PDF {
+// Option 建立 PDF 時的設定
+type Option func(p *pdfv2)
+
+func NewPDFv2(fontMap map[string]string, left, right, top, bottom float64, opts ...Option) PDF {
 	gpdf := gopdf.GoPdf{}
 	pageSize := *gopdf.PageSizeA4
-	gpdf.Start(gopdf.Config{PageSize: pageSize}) //595.28, 841.89 = A4
+	p := &pdfv2{
+		GoPdf:        &gpdf,
+		config:       gopdf.Config{PageSize: pageSize}, //595.28, 841.89 = A4
+		width:        pageSize.W,
+		height:       pageSize.H,
+		leftMargin:   left,
+		rightMargin:  right,
+		topMargin:    top,
+		bottomMargin: bottom,
+		fonts:        make(map[string]bool, len(fontMap)),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	gpdf.Start(p.config)
 	var err error
 
 	for key, value := range fontMap {
@@ -68,27 +113,71 @@ func NewPDFv2(fontMap map[string]string, left, right, top, bottom float64) PDF {
 		if err != nil {
 			panic(err)
 		}
+		p.fonts[key] = true
 	}
 	gpdf.SetLeftMargin(left)
 	gpdf.SetTopMargin(top)
-	return &pdfv2{
-		GoPdf:        &gpdf,
-		width:        pageSize.W,
-		height:       pageSize.H,
-		leftMargin:   left,
-		rightMargin:  right,
-		topMargin:    top,
-		bottomMargin: bottom,
-	}
+	return p
 }
 
 type pdfv2 struct {
 	*gopdf.GoPdf
+	config gopdf.Config
 
 	width, height             float64
 	leftMargin, topMargin     float64
 	rightMargin, bottomMargin float64
 	page                      uint8
+	fonts                     map[string]bool
+
+	watermarks   []watermark
+	finishedPage uint8
+	// 繪製頁面時發生的錯誤，於 Write 回傳
+	errs []error
+	// 目前的線條顏色及虛線，畫完邊框後還原
+	strokeColor style.Color
+	lineDash    []float64
+
+	protection *Protection
+	metadata   *Metadata
+
+	pdfa     bool
+	pdfaErrs []error
+}
+
+func (p *pdfv2) Write(w io.Writer) error {
+	p.finishPage()
+	if err := errors.Join(p.errs...); err != nil {
+		return err
+	}
+	if p.pdfa {
+		if err := p.checkPDFA(); err != nil {
+			return err
+		}
+	}
+	if p.metadata == nil {
+		return p.GoPdf.Write(w)
+	}
+	var buf bytes.Buffer
+	if err := p.GoPdf.Write(&buf); err != nil {
+		return err
+	}
+	u, err := parseUpdate(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	if p.protection != nil {
+		u.protection = p.protection.pdfProtection()
+	}
+	if err = p.writeMetadata(u); err != nil {
+		return err
+	}
+	if p.pdfa {
+		if err = p.writeOutputIntent(u); err != nil {
+			return err
+		}
+	}
+	return u.write(w)
 }
 
 func (p *pdfv2) Line(width float64) {
@@ -97,9 +186,11 @@ func (p *pdfv2) Line(width float64) {
 }
 
 func (p *pdfv2) LineWithColor(width float64, Color style.Color) {
-	p.SetStrokeColor(Color.R, Color.G, Color.B)
+	p.setStrokeColor(Color)
 	p.SetLineWidth(width)
-	p.GoPdf.Line(p.leftMargin, p.GetY(), p.width-p.rightMargin, p.GetY())
+	p.withOpacity(Color, func() {
+		p.GoPdf.Line(p.leftMargin, p.GetY(), p.width-p.rightMargin, p.GetY())
+	})
 }
 
 func (p *pdfv2) LineXY(width, x1, y1, x2, y2 float64) {
@@ -111,6 +202,10 @@ func (p *pdfv2) GetWidth() float64 {
 	return p.width - p.leftMargin - p.rightMargin
 }
 
+func (p *pdfv2) HasFont(name string) bool {
+	return p.fonts[name]
+}
+
 func (p *pdfv2) GetHeight() float64 {
 	return p.height - p.topMargin - p.bottomMargin
 }
@@ -122,8 +217,8 @@ func (p *pdfv2) GetPage() uint8 {
 func (pdf *pdfv2) Text(text string, ts style.TextStyle, align int) {
 	pdf.SetFont(ts.Font, "", ts.FontSize)
 	color := ts.Color
-	pdf.SetTextColor(color.R, color.G, color.B)
-	pdf.SetFillColor(color.R, color.G, color.B)
+	pdf.setTextColor(color)
+	pdf.setFillColor(color)
 	ox := pdf.GetX()
 	if ox < pdf.leftMargin {
 		ox = pdf.leftMargin
@@ -137,7 +232,9 @@ func (pdf *pdfv2) Text(text string, ts style.TextStyle, align int) {
 		x = pdf.width - textw - pdf.rightMargin
 	}
 	pdf.SetX(x)
-	pdf.Cell(nil, text)
+	pdf.withOpacity(color, func() {
+		pdf.Cell(nil, text)
+	})
 	pdf.SetX(ox + textw)
 }
 
@@ -156,9 +253,11 @@ func (pdf *pdfv2) TextWithPosition(text string, style style.TextStyle, x, y floa
 	pdf.SetY(y)
 
 	color := style.Color
-	pdf.SetTextColor(color.R, color.G, color.B)
-	pdf.SetFillColor(color.R, color.G, color.B)
-	pdf.Cell(nil, text)
+	pdf.setTextColor(color)
+	pdf.setFillColor(color)
+	pdf.withOpacity(color, func() {
+		pdf.Cell(nil, text)
+	})
 	pdf.SetX(ox)
 	pdf.SetY(oy)
 }
@@ -166,12 +265,14 @@ func (pdf *pdfv2) TextWithPosition(text string, style style.TextStyle, x, y floa
 func (pdf *pdfv2) TwoColumnText(text1, text2 string, ts style.TextStyle) {
 	pdf.SetFont(ts.Font, "", ts.FontSize)
 	color := ts.Color
-	pdf.SetTextColor(color.R, color.G, color.B)
-	pdf.SetFillColor(color.R, color.G, color.B)
-	pdf.SetX(pdf.leftMargin)
-	pdf.Cell(nil, text1)
-	pdf.SetX(pdf.width/2 + pdf.leftMargin)
-	pdf.Cell(nil, text2)
+	pdf.setTextColor(color)
+	pdf.setFillColor(color)
+	pdf.withOpacity(color, func() {
+		pdf.SetX(pdf.leftMargin)
+		pdf.Cell(nil, text1)
+		pdf.SetX(pdf.width/2 + pdf.leftMargin)
+		pdf.Cell(nil, text2)
+	})
 }
 
 func (pdf *pdfv2) ImageReader(imageByte io.Reader) {
@@ -180,11 +281,46 @@ func (pdf *pdfv2) ImageReader(imageByte io.Reader) {
 }
 
 func (pdf *pdfv2) ImageReaderPosition(imageByte io.Reader, x, y float64) {
+	pdf.imageReader(imageByte, x, y, nil)
+}
+
+// ImageRectDrawer 可將圖片縮放至指定矩形
+type ImageRectDrawer interface {
+	ImageReaderRect(imageByte io.Reader, x, y, w, h float64)
+}
+
+// ImageReaderRect 將圖片縮放至指定的矩形
+func ImageReaderRect(p PDF, imageByte io.Reader, x, y, w, h float64) error {
+	d, err := extension[ImageRectDrawer](p, "image rect")
+	if err != nil {
+		return err
+	}
+	d.ImageReaderRect(imageByte, x, y, w, h)
+	return nil
+}
+
+// ImageReaderRect 將圖片縮放至指定的矩形
+func (pdf *pdfv2) ImageReaderRect(imageByte io.Reader, x, y, w, h float64) {
+	pdf.imageReader(imageByte, x, y, &gopdf.Rect{W: w, H: h})
+}
+
+func (pdf *pdfv2) imageReader(imageByte io.Reader, x, y float64, rect *gopdf.Rect) {
+	if pdf.pdfa {
+		data, err := io.ReadAll(imageByte)
+		if err != nil {
+			panic(err)
+		}
+		if data, err = pdf.pdfaImage(data); err != nil {
+			pdf.pdfaErrs = append(pdf.pdfaErrs, err)
+			return
+		}
+		imageByte = bytes.NewReader(data)
+	}
 	imgH2, err := gopdf.ImageHolderByReader(imageByte)
 	if err != nil {
 		panic(err)
 	}
-	pdf.ImageByHolder(imgH2, x, y, nil)
+	pdf.ImageByHolder(imgH2, x, y, rect)
 }
 
 func (pdf *pdfv2) Br(h float64) {
@@ -193,8 +329,10 @@ func (pdf *pdfv2) Br(h float64) {
 }
 
 func (p *pdfv2) AddDirectPage(pp ...AddPagePipe) {
+	p.finishPage()
 	p.page++
 	p.GoPdf.AddPageWithOption(gopdf.PageOption{PageSize: &gopdf.Rect{W: 595.28, H: 841.89}})
+	p.drawWatermarks(true, 595.28, 841.89)
 	for _, t := range pp {
 		t.Before(p)
 	}
@@ -209,8 +347,10 @@ func (p *pdfv2) AddDirectPage(pp ...AddPagePipe) {
 }
 
 func (p *pdfv2) AddHorizontalPage(pp ...AddPagePipe) {
+	p.finishPage()
 	p.page++
 	p.GoPdf.AddPageWithOption(gopdf.PageOption{PageSize: &gopdf.Rect{W: 841.89, H: 595.28}})
+	p.drawWatermarks(true, 841.89, 595.28)
 	for _, t := range pp {
 		t.Before(p)
 	}
@@ -226,63 +366,64 @@ func (p *pdfv2) RectFillColor(text string,
 	w, h float64,
 	align, valign int,
 ) {
-	p.rectColorText(text, ts.Font, ts.FontSize, ts.Color, w, h, ts.BackGround, align, valign, "F")
+	p.textBlock(text, ts, w, h, align, valign, true, false)
 }
 
-func (pdf *pdfv2) rectColorText(text string,
+func (p *pdfv2) RectFillDrawColor(text string,
 	font string,
 	fontSize int,
 	textColor style.Color,
 	w, h float64,
 	color style.Color,
 	align, valign int,
-	rectType string,
 ) {
-	pdf.SetLineWidth(0.1)
-	pdf.SetFont(font, "", fontSize)
-	pdf.SetFillColor(color.R, color.G, color.B) //setup fill color
-	ox, x := pdf.GetX(), 0.0
-
-	if ox < pdf.leftMargin {
-		ox = pdf.leftMargin
-	}
-	x = ox
-	pdf.RectFromUpperLeftWithStyle(x, pdf.GetY(), w, h, rectType)
-	pdf.SetFillColor(0, 0, 0)
-	if align == style.AlignCenter {
-		textw, _ := pdf.MeasureTextWidth(text)
-		x = x + (w / 2) - (textw / 2)
-	} else if align == style.AlignRight {
-		textw, _ := pdf.MeasureTextWidth(text)
-		x = x + w - textw
-	} else {
-		x = x + 5
+	ts := style.TextBlockStyle{
+		TextStyle:  style.TextStyle{Font: font, FontSize: fontSize, Color: textColor},
+		BackGround: color,
 	}
+	p.textBlock(text, ts, w, h, align, valign, true, true)
+}
 
-	pdf.SetX(x)
-	oy, y := pdf.GetY(), 0.0
-	if valign == style.ValignMiddle {
-		y = oy + (h / 2) - (float64(fontSize) / 2)
-	} else if valign == style.ValignBottom {
-		y = oy + h - float64(fontSize)
-	}
-	pdf.SetY(y)
+// tableCell 表格儲存格，置中並套用 ts 的框線及內距
+func (p *pdfv2) tableCell(text string, ts style.TextBlockStyle, w, h float64) {
+	p.textBlock(text, ts, w, h, style.AlignCenter, style.ValignMiddle, true, true)
+}
 
-	pdf.SetTextColor(textColor.R, textColor.G, textColor.B)
-	pdf.Cell(nil, text)
-	pdf.SetY(oy)
-	pdf.SetX(ox + w)
+// severityStyle 取得警示等級的樣式，未設定的等級沿用相近的樣式
+func severityStyle(ts style.FixRowColumnTableStyle, sev Severity) style.TextBlockStyle {
+	pick := func(styles ...style.TextBlockStyle) style.TextBlockStyle {
+		for _, s := range styles {
+			if s != (style.TextBlockStyle{}) {
+				return s
+			}
+		}
+		return ts.Content
+	}
+	switch sev {
+	case SeverityHighAlarm:
+		return ts.HeatAlertContent
+	case SeverityLowAlarm:
+		return ts.CoolAlertContent
+	case SeverityHighWarn:
+		return pick(ts.HeatWarnContent, ts.HeatAlertContent)
+	case SeverityLowWarn:
+		return pick(ts.CoolWarnContent, ts.CoolAlertContent)
+	case SeverityRate:
+		return pick(ts.RateAlertContent, ts.HeatAlertContent)
+	case SeverityMissing:
+		return pick(ts.MissingContent, ts.BlankContent)
+	}
+	return ts.Content
 }
 
-func (p *pdfv2) RectFillDrawColor(text string,
-	font string,
-	fontSize int,
-	textColor style.Color,
-	w, h float64,
-	color style.Color,
-	align, valign int,
-) {
-	p.rectColorText(text, font, fontSize, textColor, w, h, color, align, valign, "FD")
+// sensorCellStyle 依警示狀態取得內容儲存格樣式，字型沿用 RowHeader
+func sensorCellStyle(ts style.FixRowColumnTableStyle, c SensorCell) style.TextBlockStyle {
+	cell := severityStyle(ts, c.severity())
+	if c.IsHeader && c.severity() == SeverityNormal {
+		cell = ts.RowHeader
+	}
+	cell.Font, cell.FontSize = ts.RowHeader.Font, ts.RowHeader.FontSize
+	return cell
 }
 
 func (pdf *pdfv2) DrawSensorTable(nti *sensorTableIter, ts style.FixRowColumnTableStyle) {
@@ -299,9 +440,9 @@ func (pdf *pdfv2) DrawSensorTable(nti *sensorTableIter, ts style.FixRowColumnTab
 	i := 0
 	for _, h := range nti.header {
 		if i == 0 {
-			pdf.RectFillDrawColor(h, ts.ColumnHeader.Font, ts.ColumnHeader.FontSize, ts.ColumnHeader.Color, ts.ColumnHeader.W, 20, ts.ColumnHeader.BackGround, style.AlignCenter, style.ValignMiddle)
+			pdf.tableCell(h, ts.ColumnHeader, ts.ColumnHeader.W, 20)
 		} else {
-			pdf.RectFillDrawColor(h, ts.RowHeader.Font, ts.RowHeader.FontSize, ts.RowHeader.Color, ts.RowHeader.W, 20, ts.RowHeader.BackGround, style.AlignCenter, style.ValignMiddle)
+			pdf.tableCell(h, ts.RowHeader, ts.RowHeader.W, 20)
 		}
 		i++
 	}
@@ -310,15 +451,9 @@ func (pdf *pdfv2) DrawSensorTable(nti *sensorTableIter, ts style.FixRowColumnTab
 		i = 0
 		for _, h := range r {
 			if i == 0 {
-				pdf.RectFillDrawColor(h.Value, ts.ColumnHeader.Font, ts.ColumnHeader.FontSize, ts.ColumnHeader.Color, ts.ColumnHeader.W, 20, ts.ColumnHeader.BackGround, style.AlignCenter, style.ValignMiddle)
-			} else if h.IsAlert == 1 {
-				pdf.RectFillDrawColor(h.Value, ts.RowHeader.Font, ts.RowHeader.FontSize, ts.HeatAlertContent.Color, ts.RowHeader.W, 20, ts.HeatAlertContent.BackGround, style.AlignCenter, style.ValignMiddle)
-			} else if h.IsAlert == -1 {
-				pdf.RectFillDrawColor(h.Value, ts.RowHeader.Font, ts.RowHeader.FontSize, ts.CoolAlertContent.Color, ts.RowHeader.W, 20, ts.CoolAlertContent.BackGround, style.AlignCenter, style.ValignMiddle)
-			} else if h.IsHeader {
-				pdf.RectFillDrawColor(h.Value, ts.RowHeader.Font, ts.RowHeader.FontSize, ts.RowHeader.Color, ts.RowHeader.W, 20, ts.RowHeader.BackGround, style.AlignCenter, style.ValignMiddle)
+				pdf.tableCell(h.Value, ts.ColumnHeader, ts.ColumnHeader.W, 20)
 			} else {
-				pdf.RectFillDrawColor(h.Value, ts.RowHeader.Font, ts.RowHeader.FontSize, ts.Content.Color, ts.RowHeader.W, 20, ts.Content.BackGround, style.AlignCenter, style.ValignMiddle)
+				pdf.tableCell(h.Value, sensorCellStyle(ts, h), ts.RowHeader.W, 20)
 			}
 			i++
 		}
@@ -326,129 +461,88 @@ func (pdf *pdfv2) DrawSensorTable(nti *sensorTableIter, ts style.FixRowColumnTab
 	}
 }
 
-func (pdf *pdfv2) DrawSensorMergeTable(nti *sensorTableIter, pageRows int, mergeRows int, ts style.FixRowColumnTableStyle, pp ...AddPagePipe) {
-	ox, x := pdf.GetX(), 0.0
+// addSamePage 新增與目前頁面方向相同的頁面
+func (pdf *pdfv2) addSamePage(pp ...AddPagePipe) {
+	if pdf.width > pdf.height {
+		pdf.AddHorizontalPage(pp...)
+	} else {
+		pdf.AddDirectPage(pp...)
+	}
+}
 
-	if ox < pdf.leftMargin {
-		ox = pdf.leftMargin
+// mergeTableDay 畫合併表格的一天，values 依 ts 的版面折成多組標題/數值列。
+// ts 未設定 MergeLines 時，以 mergeRows (標題列加數值列的總列數) 決定組數
+func (pdf *pdfv2) mergeTableDay(label string, header []string, values []SensorCell, mergeRows int, ts style.FixRowColumnTableStyle) {
+	if ts.MergeLines <= 0 && mergeRows > 0 {
+		ts.MergeLines = mergeRows / 2
+	}
+	columns, lines, rowH := ts.MergeLayout(len(values))
+	headerHeight := float64(lines*2) * rowH
+	pdf.tableCell(label, ts.ColumnHeader, ts.ColumnHeader.W, headerHeight)
+	x := pdf.leftMargin + ts.ColumnHeader.W
+	for line := 0; line < lines; line++ {
+		start := line * columns
+		end := start + columns
+		if end > len(values) {
+			end = len(values)
+		}
+		for k := start; k < end; k++ {
+			h := ""
+			if k < len(header) {
+				h = header[k]
+			}
+			pdf.tableCell(h, ts.ColumnHeader, ts.RowHeader.W, rowH)
+		}
+		pdf.Br(rowH)
+		pdf.SetX(x)
+		for k := start; k < end; k++ {
+			pdf.tableCell(values[k].Value, sensorCellStyle(ts, SensorCell{IsAlert: values[k].IsAlert, Severity: values[k].Severity}), ts.RowHeader.W, rowH)
+		}
+		pdf.Br(rowH)
+		pdf.SetX(x)
+	}
+	pdf.SetX(pdf.leftMargin)
+}
+
+// DrawSensorMergeTable 每列第一格為日期，其餘讀值依 ts 的 MergeColumns、MergeLines、RowHeight 折行，
+// 每 pageRows 天換頁，新頁面與目前頁面方向相同
+func (pdf *pdfv2) DrawSensorMergeTable(nti *sensorTableIter, pageRows int, mergeRows int, ts style.FixRowColumnTableStyle, pp ...AddPagePipe) {
+	if pdf.GetX() < pdf.leftMargin {
+		pdf.SetX(pdf.leftMargin)
 	}
-	x = ox
-	pdf.SetX(x)
-	pdf.SetY(pdf.GetY())
-	const columnsCount = 12
-	i, j, k := 0, 0, 0
 	day := 1
-	headerLen := len(nti.header)
-	headerHeight := float64(mergeRows) * 20
 	pageRows++
 	for _, r := range nti.rows {
 		if day%pageRows == 0 {
-			pdf.AddDirectPage(pp...)
-		}
-		i, k = 0, 0
-		for _, h := range r {
-			if i == 0 {
-				if h.Value == "" {
-					break
-				}
-				pdf.RectFillDrawColor(h.Value, ts.ColumnHeader.Font, ts.ColumnHeader.FontSize, ts.ColumnHeader.Color, ts.ColumnHeader.W, headerHeight, ts.ColumnHeader.BackGround, style.AlignCenter, style.ValignMiddle)
-				j, k = 0, 0
-			} else {
-				innerRowCount := j / 2
-				if j%2 == 0 {
-					for k = j / 2 * columnsCount; k < headerLen; k++ {
-						if k/columnsCount != innerRowCount && k%columnsCount == 0 {
-							break
-						}
-						pdf.RectFillDrawColor(nti.header[k], ts.ColumnHeader.Font, ts.ColumnHeader.FontSize, ts.ColumnHeader.Color, ts.RowHeader.W, 20, ts.ColumnHeader.BackGround, style.AlignCenter, style.ValignMiddle)
-					}
-					pdf.Br(20)
-					pdf.SetX(pdf.leftMargin + ts.ColumnHeader.W)
-					j++
-				}
-				if h.IsAlert == 1 {
-					pdf.RectFillDrawColor(h.Value, ts.RowHeader.Font, ts.RowHeader.FontSize, ts.HeatAlertContent.Color, ts.RowHeader.W, 20, ts.HeatAlertContent.BackGround, style.AlignCenter, style.ValignMiddle)
-				} else if h.IsAlert == -1 {
-					pdf.RectFillDrawColor(h.Value, ts.RowHeader.Font, ts.RowHeader.FontSize, ts.CoolAlertContent.Color, ts.RowHeader.W, 20, ts.CoolAlertContent.BackGround, style.AlignCenter, style.ValignMiddle)
-				} else {
-					pdf.RectFillDrawColor(h.Value, ts.RowHeader.Font, ts.RowHeader.FontSize, ts.Content.Color, ts.RowHeader.W, 20, ts.Content.BackGround, style.AlignCenter, style.ValignMiddle)
-				}
-				if i/columnsCount != innerRowCount && i%columnsCount == 0 {
-					pdf.Br(20)
-					pdf.SetX(pdf.leftMargin + ts.ColumnHeader.W)
-					j++
-				}
-			}
-			i++
+			pdf.addSamePage(pp...)
 		}
 		day++
-		pdf.SetX(pdf.leftMargin)
+		if len(r) == 0 || r[0].Value == "" {
+			continue
+		}
+		pdf.mergeTableDay(r[0].Value, nti.header, r[1:], mergeRows, ts)
 	}
 }
 
+// DrawSensorDynamicHeaderMergeTable 與 DrawSensorMergeTable 相同，但每天使用各自的標題
 func (pdf *pdfv2) DrawSensorDynamicHeaderMergeTable(nti *sensorDynamicHeaderTableIter, pageRows int, mergeRows int, ts style.FixRowColumnTableStyle, pp ...AddPagePipe) {
-	ox, x := pdf.GetX(), 0.0
-
-	if ox < pdf.leftMargin {
-		ox = pdf.leftMargin
+	if pdf.GetX() < pdf.leftMargin {
+		pdf.SetX(pdf.leftMargin)
 	}
-	x = ox
-	pdf.SetX(x)
-	pdf.SetY(pdf.GetY())
-	const columnsCount = 12
-	i, j, k := 0, 0, 0
 	day := 1
-	var header []string
-	var headerLen int
-
-	headerHeight := float64(mergeRows) * 20
 	pageRows++
 	addPage := true
 	for _, r := range nti.rows {
-		header = nti.header[day-1]
-		headerLen = len(header)
+		header := nti.header[day-1]
 		if day%pageRows == 0 && addPage {
-			pdf.AddDirectPage(pp...)
-		}
-		i, k = 0, 0
-		for index, h := range r {
-			if i == 0 {
-				if r[index+1].Value == "-" {
-					addPage = false
-					break
-				}
-				pdf.RectFillDrawColor(h.Value, ts.ColumnHeader.Font, ts.ColumnHeader.FontSize, ts.ColumnHeader.Color, ts.ColumnHeader.W, headerHeight, ts.ColumnHeader.BackGround, style.AlignCenter, style.ValignMiddle)
-				j, k = 0, 0
-			} else {
-				innerRowCount := j / 2
-				if j%2 == 0 {
-					for k = j / 2 * columnsCount; k < headerLen; k++ {
-						if k/columnsCount != innerRowCount && k%columnsCount == 0 {
-							break
-						}
-						pdf.RectFillDrawColor(header[k], ts.ColumnHeader.Font, ts.ColumnHeader.FontSize, ts.ColumnHeader.Color, ts.RowHeader.W, 20, ts.ColumnHeader.BackGround, style.AlignCenter, style.ValignMiddle)
-					}
-					pdf.Br(20)
-					pdf.SetX(pdf.leftMargin + ts.ColumnHeader.W)
-					j++
-				}
-				if h.IsAlert == 1 {
-					pdf.RectFillDrawColor(h.Value, ts.RowHeader.Font, ts.RowHeader.FontSize, ts.HeatAlertContent.Color, ts.RowHeader.W, 20, ts.HeatAlertContent.BackGround, style.AlignCenter, style.ValignMiddle)
-				} else if h.IsAlert == -1 {
-					pdf.RectFillDrawColor(h.Value, ts.RowHeader.Font, ts.RowHeader.FontSize, ts.CoolAlertContent.Color, ts.RowHeader.W, 20, ts.CoolAlertContent.BackGround, style.AlignCenter, style.ValignMiddle)
-				} else {
-					pdf.RectFillDrawColor(h.Value, ts.RowHeader.Font, ts.RowHeader.FontSize, ts.Content.Color, ts.RowHeader.W, 20, ts.Content.BackGround, style.AlignCenter, style.ValignMiddle)
-				}
-				if i/columnsCount != innerRowCount && i%columnsCount == 0 {
-					pdf.Br(20)
-					pdf.SetX(pdf.leftMargin + ts.ColumnHeader.W)
-					j++
-				}
-			}
-			i++
+			pdf.addSamePage(pp...)
 		}
 		day++
-		pdf.SetX(pdf.leftMargin)
+		if len(r) < 2 || r[1].Value == "-" {
+			addPage = false
+			continue
+		}
+		pdf.mergeTableDay(r[0].Value, header, r[1:], mergeRows, ts)
 	}
 }
 
@@ -463,7 +557,7 @@ func (pdf *pdfv2) DrawSenStateChartTable(nti *sensorTableIter, ts style.FixRowCo
 
 	pdf.SetY(pdf.GetY())
 
-	i := 0
+	i, ax := 0, 0.0
 	for n, r := range nti.rows {
 
 		if n%4 == 0 {
@@ -478,6 +572,11 @@ func (pdf *pdfv2) DrawSenStateChartTable(nti *sensorTableIter, ts style.FixRowCo
 				i++
 			}
 			pdf.RectFillColor("60分", ts.ChartHeader, ts.RowHeader.W, 20, style.AlignLeft, style.ValignBottom)
+			// 資料可用率欄位接在時間標題之後，避免與「60分」重疊
+			ax = pdf.GetX()
+			if ts.ShowAvailability {
+				pdf.RectFillColor("可用率", ts.ChartHeader, ts.RowHeader.W, 20, style.AlignCenter, style.ValignBottom)
+			}
 			pdf.Br(22)
 			l := float64(len(nti.header))
 			x, y := pdf.GetX()+ts.ColumnHeader.W, pdf.GetY()
@@ -491,20 +590,38 @@ func (pdf *pdfv2) DrawSenStateChartTable(nti *sensorTableIter, ts style.FixRowCo
 		i = 0
 		for _, h := range r {
 			if i == 0 {
-				pdf.RectFillDrawColor(h.Value, ts.ColumnHeader.Font, ts.ColumnHeader.FontSize, ts.ColumnHeader.Color, ts.ColumnHeader.W, 20, ts.ColumnHeader.BackGround, style.AlignCenter, style.ValignMiddle)
-			} else if h.IsAlert != 0 {
-				pdf.RectFillDrawColor("", ts.RowHeader.Font, ts.RowHeader.FontSize, ts.HeatAlertContent.Color, ts.RowHeader.W/5, 20, ts.HeatAlertContent.BackGround, style.AlignCenter, style.ValignMiddle)
-			} else if h.Value == "-" {
-				pdf.RectFillDrawColor("", ts.RowHeader.Font, ts.RowHeader.FontSize, ts.HeatAlertContent.Color, ts.RowHeader.W/5, 20, ts.BlankContent.BackGround, style.AlignCenter, style.ValignMiddle)
+				pdf.tableCell(h.Value, ts.ColumnHeader, ts.ColumnHeader.W, 20)
+			} else if h.severity() == SeverityMissing || h.Value == "-" {
+				pdf.tableCell("", ts.BlankContent, ts.RowHeader.W/5, 20)
+			} else if h.severity() != SeverityNormal {
+				pdf.tableCell("", severityStyle(ts, h.severity()), ts.RowHeader.W/5, 20)
 			} else {
-				pdf.RectFillDrawColor("", ts.RowHeader.Font, ts.RowHeader.FontSize, ts.Content.Color, ts.RowHeader.W/5, 20, ts.Content.BackGround, style.AlignCenter, style.ValignMiddle)
+				pdf.tableCell("", ts.Content, ts.RowHeader.W/5, 20)
 			}
 			i++
 		}
+		if ts.ShowAvailability {
+			pdf.SetX(ax)
+			pdf.tableCell(fmt.Sprintf("%.1f%%", availability(r[1:])*100), ts.Content, ts.RowHeader.W, 20)
+		}
 		pdf.Br(20)
 	}
 }
 
+// availability 有讀值的格數比例 (0-1)
+func availability(cells []SensorCell) float64 {
+	if len(cells) == 0 {
+		return 0
+	}
+	n := 0
+	for _, c := range cells {
+		if c.severity() != SeverityMissing && c.Value != "-" {
+			n++
+		}
+	}
+	return float64(n) / float64(len(cells))
+}
+
 func (pdf *pdfv2) DrawSenStateTable(nti *sensorTableIter, ts style.StateTableStyle, pp ...AddPagePipe) {
 
 	ox, x := pdf.GetX(), 0.0
@@ -518,12 +635,15 @@ func (pdf *pdfv2) DrawSenStateTable(nti *sensorTableIter, ts style.StateTableSty
 	//oy, y := pdf.GetY(), pdf.GetY()
 	pdf.SetY(pdf.GetY())
 	i := 0
+	timeHeader, stateHeader := ts.ColumnTime, ts.ColumnState
+	timeHeader.BackGround, stateHeader.BackGround = ts.HeaderBackground, ts.HeaderBackground
+	timeHeader.Gradient, stateHeader.Gradient = nil, nil
 	drawTableHeader := func() {
 		for _, h := range nti.header {
 			if i%2 == 0 {
-				pdf.RectFillDrawColor(h, ts.ColumnTime.Font, ts.ColumnTime.FontSize, ts.ColumnTime.Color, ts.ColumnTime.W, 20, ts.HeaderBackground, style.AlignCenter, style.ValignMiddle)
+				pdf.tableCell(h, timeHeader, ts.ColumnTime.W, 20)
 			} else {
-				pdf.RectFillDrawColor(h, ts.ColumnState.Font, ts.ColumnState.FontSize, ts.ColumnState.Color, ts.ColumnState.W, 20, ts.HeaderBackground, style.AlignCenter, style.ValignMiddle)
+				pdf.tableCell(h, stateHeader, ts.ColumnState.W, 20)
 			}
 			i++
 		}
@@ -541,9 +661,9 @@ func (pdf *pdfv2) DrawSenStateTable(nti *sensorTableIter, ts style.StateTableSty
 		i = 0
 		for _, h := range r {
 			if i%2 == 0 {
-				pdf.RectFillDrawColor(h.Value, ts.ColumnTime.Font, ts.ColumnTime.FontSize, ts.ColumnTime.Color, ts.ColumnTime.W, 20, ts.ColumnTime.BackGround, style.AlignCenter, style.ValignMiddle)
+				pdf.tableCell(h.Value, ts.ColumnTime, ts.ColumnTime.W, 20)
 			} else {
-				pdf.RectFillDrawColor(h.Value, ts.ColumnState.Font, ts.ColumnState.FontSize, ts.ColumnState.Color, ts.ColumnState.W, 20, ts.ColumnState.BackGround, style.AlignCenter, style.ValignMiddle)
+				pdf.tableCell(h.Value, ts.ColumnState, ts.ColumnState.W, 20)
 			}
 			i++
 		}
@@ -551,3 +671,64 @@ func (pdf *pdfv2) DrawSenStateTable(nti *sensorTableIter, ts style.StateTableSty
 		rowCount++
 	}
 }
+
+// EventTableDrawer 可繪製事件表格
+type EventTableDrawer interface {
+	DrawEventTable(nti *sensorTableIter, ts style.EventTableStyle, pp ...AddPagePipe)
+}
+
+// DrawEventTable 事件表格，每 MaxRowCount 列換頁並重畫標題
+func DrawEventTable(p PDF, nti *sensorTableIter, ts style.EventTableStyle, pp ...AddPagePipe) error {
+	d, err := extension[EventTableDrawer](p, "event table")
+	if err != nil {
+		return err
+	}
+	d.DrawEventTable(nti, ts, pp...)
+	return nil
+}
+
+func (pdf *pdfv2) DrawEventTable(nti *sensorTableIter, ts style.EventTableStyle, pp ...AddPagePipe) {
+	if pdf.GetX() < pdf.leftMargin {
+		pdf.SetX(pdf.leftMargin)
+	}
+	column := func(i int) style.TextBlockStyle {
+		if len(ts.Columns) == 0 {
+			return style.TextBlockStyle{}
+		}
+		if i >= len(ts.Columns) {
+			i = len(ts.Columns) - 1
+		}
+		return ts.Columns[i]
+	}
+	drawTableHeader := func() {
+		for i, h := range nti.header {
+			c := column(i)
+			c.BackGround, c.Gradient = ts.HeaderBackground, nil
+			pdf.tableCell(h, c, c.W, 20)
+		}
+		pdf.Br(20)
+	}
+	drawTableHeader()
+
+	for rowCount, r := range nti.rows {
+		if rowCount != 0 && ts.MaxRowCount > 0 && rowCount%ts.MaxRowCount == 0 {
+			pdf.addSamePage(pp...)
+			drawTableHeader()
+		}
+		for i, h := range r {
+			c := column(i)
+			switch h.severity() {
+			case SeverityHighWarn, SeverityHighAlarm:
+				if ts.HeatAlertBackground != (style.Color{}) {
+					c.BackGround, c.Gradient = ts.HeatAlertBackground, nil
+				}
+			case SeverityLowWarn, SeverityLowAlarm:
+				if ts.CoolAlertBackground != (style.Color{}) {
+					c.BackGround, c.Gradient = ts.CoolAlertBackground, nil
+				}
+			}
+			pdf.tableCell(h.Value, c, c.W, 20)
+		}
+		pdf.Br(20)
+	}
+}
@@ -0,0 +1,43 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/png"
+	"testing"
+
+	"github.com/94peter/export/pdf/style"
+	"github.com/stretchr/testify/assert"
+)
+
+// wrappedPDF 只實作 PDF 介面的外部實作
+type wrappedPDF struct {
+	PDF
+}
+
+func Test_OptionalInterfaces(t *testing.T) {
+	p := NewPDFv2(testFontMap(t), 20, 20, 20, 20)
+	assert.True(t, HasFont(p, "tw-r"))
+	assert.False(t, HasFont(p, "tw-x"))
+	assert.False(t, HasFont(wrappedPDF{p}, "tw-r"))
+
+	p.AddDirectPage()
+	assert.NoError(t, ImageReaderRect(p, bytes.NewReader(testPNG(t)), 20, 20, 40, 20))
+	assert.ErrorIs(t, ImageReaderRect(wrappedPDF{p}, bytes.NewReader(testPNG(t)), 20, 20, 40, 20), errors.ErrUnsupported)
+	assert.ErrorIs(t, AddWatermark(wrappedPDF{p}, Watermark{Text: "DRAFT"}), errors.ErrUnsupported)
+	assert.ErrorIs(t, SetMetadata(wrappedPDF{p}, Metadata{Title: "T"}), errors.ErrUnsupported)
+	assert.ErrorIs(t, DrawEventTable(wrappedPDF{p}, GetSensorTableIter([]string{"Start"}), style.DefaultEventTableStyle), errors.ErrUnsupported)
+	ts := style.TextBlockStyle{TextStyle: style.TextStyle{Font: "tw-r", FontSize: 10}}
+	assert.NoError(t, TextBlockXY(p, "OK", ts, 20, 60, 40, 20, style.AlignLeft, style.ValignTop))
+	assert.ErrorIs(t, TextBlockXY(wrappedPDF{p}, "OK", ts, 20, 60, 40, 20, style.AlignLeft, style.ValignTop), errors.ErrUnsupported)
+}
+
+func testPNG(t *testing.T) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 4))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
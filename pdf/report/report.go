@@ -0,0 +1,459 @@
+// Package report 以 pdf、alert、stats 及 mychart 組合出感測器日報、週報及月報，三者的統計表格分組單位及預設樣式不同
+package report
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/94peter/export/pdf"
+	"github.com/94peter/export/pdf/alert"
+	"github.com/94peter/export/pdf/mychart"
+	"github.com/94peter/export/pdf/stats"
+	"github.com/94peter/export/pdf/style"
+)
+
+// Sensor 一個感測器的原始讀值及警示規則
+type Sensor struct {
+	Name     string
+	Unit     string
+	Readings []alert.Reading
+	Rule     alert.Rule
+	// 預期的取樣間隔，用於計算資料可用率，0 時只計算值為 NaN 的讀值
+	Interval time.Duration
+}
+
+// Period 報表的時間範圍 [Start, End)，Location 為 nil 時使用 Start 的時區
+type Period struct {
+	Start, End time.Time
+	Location   *time.Location
+}
+
+func (p Period) location() *time.Location {
+	if p.Location != nil {
+		return p.Location
+	}
+	return p.Start.Location()
+}
+
+// Builder 產生報表，fontMap 須包含樣式使用的字型，為空時回傳錯誤
+type Builder struct {
+	fontMap map[string]string
+	// 報表作者，寫入文件資訊
+	Author string
+	// 建立 PDF 時的設定，例如 pdf.WithPDFA()
+	Options []pdf.Option
+	// 統計表格的樣式，nil 時依報表使用 DefaultDialySensorStyle、DefaultWeeklySensorStyle 或 DefaultMonthlySensorStyle
+	TableStyle *style.SensorReportStyle
+}
+
+func NewBuilder(fontMap map[string]string) *Builder {
+	return &Builder{fontMap: fontMap}
+}
+
+const margin = 20
+
+// kind 日報、週報及月報的標題、統計表格的分組單位、時間格式及預設樣式
+type kind struct {
+	title  string
+	bucket stats.Period
+	layout string
+	style  *style.SensorReportStyle
+}
+
+var (
+	daily   = kind{title: "Daily Report", bucket: stats.Hour, layout: "15", style: style.DefaultDialySensorStyle}
+	weekly  = kind{title: "Weekly Report", bucket: stats.Day, layout: "01/02", style: style.DefaultWeeklySensorStyle}
+	monthly = kind{title: "Monthly Report", bucket: stats.Day, layout: "01/02", style: style.DefaultMonthlySensorStyle}
+)
+
+// BuildDaily 日報，統計表格每小時一列
+func (b *Builder) BuildDaily(ctx context.Context, sensors []Sensor, period Period, s style.SensorV3ReportStyle) (pdf.PDF, error) {
+	return b.build(ctx, daily, sensors, period, s)
+}
+
+// BuildWeekly 週報，統計表格每天一列
+func (b *Builder) BuildWeekly(ctx context.Context, sensors []Sensor, period Period, s style.SensorV3ReportStyle) (pdf.PDF, error) {
+	return b.build(ctx, weekly, sensors, period, s)
+}
+
+// BuildMonthly 月報，統計表格每天一列
+func (b *Builder) BuildMonthly(ctx context.Context, sensors []Sensor, period Period, s style.SensorV3ReportStyle) (pdf.PDF, error) {
+	return b.build(ctx, monthly, sensors, period, s)
+}
+
+// sensorData 報表期間內的讀值及統計
+type sensorData struct {
+	Sensor
+	readings []alert.Reading
+	hourly   *stats.Series
+	// 統計表格的分組
+	table  *stats.Series
+	alarms int
+}
+
+// build 依序產生封面、摘要、統計表格及各感測器明細，明細包含門檻、趨勢圖、
+// 每天一列的每小時平均值、狀態變化及超出門檻的事件
+func (b *Builder) build(ctx context.Context, k kind, sensors []Sensor, period Period, s style.SensorV3ReportStyle) (pdf.PDF, error) {
+	if len(b.fontMap) == 0 {
+		return nil, errors.New("report: fontMap is empty")
+	}
+	if !period.End.After(period.Start) {
+		return nil, fmt.Errorf("report: invalid period %v - %v", period.Start, period.End)
+	}
+	ts := b.TableStyle
+	if ts == nil {
+		ts = k.style
+	}
+	p := pdf.NewPDFv2(b.fontMap, margin, margin, margin, margin, b.Options...)
+	if sp, ok := p.(style.PDF); ok {
+		if err := s.Validate(sp); err != nil {
+			return nil, fmt.Errorf("report: %w", err)
+		}
+		if err := ts.Validate(sp); err != nil {
+			return nil, fmt.Errorf("report: table style: %w", err)
+		}
+	}
+	loc := period.location()
+	data := make([]*sensorData, len(sensors))
+	for i, sen := range sensors {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		d, err := newSensorData(sen, period, loc, k.bucket)
+		if err != nil {
+			return nil, fmt.Errorf("report: sensor %s: %w", sen.Name, err)
+		}
+		data[i] = d
+	}
+
+	err := pdf.SetMetadata(p, pdf.Metadata{
+		Title:   k.title,
+		Author:  b.Author,
+		Subject: periodText(period, loc),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("report: %w", err)
+	}
+	b.cover(p, k.title, data, period, s)
+	if err := b.summary(p, data, s); err != nil {
+		return nil, err
+	}
+	if err := b.statsTable(p, k, data, ts); err != nil {
+		return nil, fmt.Errorf("report: %w", err)
+	}
+	for _, d := range data {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := b.sensor(p, d, period, s); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func newSensorData(sen Sensor, period Period, loc *time.Location, bucket stats.Period) (*sensorData, error) {
+	d := &sensorData{Sensor: sen}
+	for _, r := range sen.Readings {
+		if !r.Time.Before(period.Start) && r.Time.Before(period.End) {
+			d.readings = append(d.readings, r)
+		}
+	}
+	opt := stats.Options{Location: loc, Interval: sen.Interval, Upper: sen.Rule.HighAlarm, Lower: sen.Rule.LowAlarm}
+	d.hourly = stats.Compute(d.readings, stats.Hour, opt)
+	d.table = d.hourly
+	if bucket != stats.Hour {
+		d.table = stats.Compute(d.readings, bucket, opt)
+	}
+	sevs, err := sen.Rule.Evaluate(d.readings)
+	if err != nil {
+		return nil, err
+	}
+	for _, sev := range sevs {
+		if sev == pdf.SeverityHighAlarm || sev == pdf.SeverityLowAlarm {
+			d.alarms++
+		}
+	}
+	return d, nil
+}
+
+func periodText(period Period, loc *time.Location) string {
+	const layout = "2006-01-02 15:04"
+	return period.Start.In(loc).Format(layout) + " ~ " + period.End.In(loc).Format(layout)
+}
+
+func formatValue(v float64) string {
+	if math.IsNaN(v) {
+		return "-"
+	}
+	return strconv.FormatFloat(v, 'f', 1, 64)
+}
+
+func formatPercent(v float64) string {
+	return strconv.FormatFloat(v*100, 'f', 1, 64) + "%"
+}
+
+func limitText(name string, v *float64) string {
+	if v == nil {
+		return name + ": -"
+	}
+	return name + ": " + formatValue(*v)
+}
+
+// cover 封面，列出報表期間及感測器
+func (b *Builder) cover(p pdf.PDF, title string, data []*sensorData, period Period, s style.SensorV3ReportStyle) {
+	p.AddDirectPage()
+	p.Br(p.GetHeight() / 3)
+	p.Text(title, s.Header, style.AlignCenter)
+	p.Br(float64(s.Header.FontSize) * 2)
+	p.Text(periodText(period, period.location()), s.Title, style.AlignCenter)
+	p.Br(float64(s.Title.FontSize) * 3)
+	for _, d := range data {
+		p.Text(d.Name, s.Content, style.AlignCenter)
+		p.Br(float64(s.Content.FontSize) * 1.5)
+	}
+}
+
+// summary 各感測器整段期間的統計，超出警報門檻的值以警示樣式標示
+func (b *Builder) summary(p pdf.PDF, data []*sensorData, s style.SensorV3ReportStyle) error {
+	p.AddDirectPage()
+	p.Text("Summary", s.Title, style.AlignLeft)
+	p.Br(float64(s.Title.FontSize) * 2)
+	nti := pdf.GetSensorTableIter([]string{"Sensor", "Max", "Min", "Avg", "MKT", "Alarms", "Missing", "Avail."})
+	for _, d := range data {
+		t := d.hourly.Total()
+		maxSev, err := severity(d.Rule, t.Max)
+		if err != nil {
+			return err
+		}
+		minSev, err := severity(d.Rule, t.Min)
+		if err != nil {
+			return err
+		}
+		nti.AddRow([]pdf.SensorCell{
+			{Value: d.Name, IsHeader: true},
+			{Value: formatValue(t.Max), Severity: maxSev},
+			{Value: formatValue(t.Min), Severity: minSev},
+			{Value: formatValue(t.Avg)},
+			{Value: formatValue(t.MKT)},
+			{Value: strconv.Itoa(d.alarms)},
+			{Value: strconv.Itoa(t.Missing)},
+			{Value: formatPercent(t.Availability())},
+		})
+	}
+	p.DrawSensorTable(nti, s.TableStyle)
+	return nil
+}
+
+// statsTable 各感測器每個分組的最大、平均、最小值，一個感測器一欄，畫不下時換頁
+func (b *Builder) statsTable(p pdf.PDF, k kind, data []*sensorData, s *style.SensorReportStyle) error {
+	if len(data) == 0 {
+		return nil
+	}
+	p.AddDirectPage()
+	p.Text("Statistics", s.Title, style.AlignLeft)
+	p.Br(float64(s.Title.FontSize) * 2)
+	for _, desc := range []struct {
+		text string
+		ts   style.TextStyle
+	}{{"Time", s.TableDesc}, {"Max", s.TableDescMax}, {"Avg", s.TableDescAvg}, {"Min", s.TableDescMin}} {
+		p.Text(desc.text+"    ", desc.ts, style.AlignLeft)
+	}
+	p.Br(float64(s.TableDesc.FontSize) * 2)
+
+	iters := make([]*pdf.TableValueAryIter, len(data))
+	rows := 1
+	for i, d := range data {
+		iters[i] = d.table.TableIter(d.Name, k.layout)
+		rows = max(rows, len(d.table.Buckets))
+	}
+	// 每列為 20 高的標題加上資料，PageRowNumber 超出頁面時以頁面可容納的列數為準
+	rowH := 20 + float64(s.TableStyle.Data[0].FontSize*rows)
+	ts := s.TableStyle
+	for len(iters) > 0 {
+		fit := max(1, int((p.GetHeight()+margin-p.GetY())/rowH))
+		ts.PageRowNumber = s.TableStyle.PageRowNumber
+		if ts.PageRowNumber <= 0 || ts.PageRowNumber > fit {
+			ts.PageRowNumber = fit
+		}
+		rest, err := pdf.DrawTimeValueTable(p, iters, ts)
+		if err != nil {
+			return err
+		}
+		if iters = rest; len(iters) > 0 {
+			p.AddDirectPage()
+		}
+	}
+	return nil
+}
+
+func severity(rule alert.Rule, v float64) (pdf.Severity, error) {
+	if math.IsNaN(v) {
+		return pdf.SeverityMissing, nil
+	}
+	rule.Hysteresis, rule.MaxRate = 0, 0
+	e, err := alert.NewEvaluator(rule)
+	if err != nil {
+		return 0, err
+	}
+	return e.Next(alert.Reading{Value: v}), nil
+}
+
+// sensor 感測器明細：門檻、趨勢圖、每小時平均值、狀態變化及超出門檻的事件
+func (b *Builder) sensor(p pdf.PDF, d *sensorData, period Period, s style.SensorV3ReportStyle) error {
+	p.AddDirectPage()
+	name := d.Name
+	if d.Unit != "" {
+		name += " (" + d.Unit + ")"
+	}
+	p.RectFillColor(name, s.SectionBlock, p.GetWidth(), 24, style.AlignLeft, style.ValignMiddle)
+	p.Br(30)
+	r := d.Rule
+	p.Text(limitText("High alarm", r.HighAlarm)+"    "+limitText("High warn", r.HighWarn)+"    "+
+		limitText("Low warn", r.LowWarn)+"    "+limitText("Low alarm", r.LowAlarm), s.SubTitle, style.AlignLeft)
+	p.Br(float64(s.SubTitle.FontSize) * 2)
+
+	if err := b.chart(p, d, s); err != nil {
+		return err
+	}
+
+	if len(d.hourly.Buckets) > 0 {
+		p.AddDirectPage()
+		p.Text("Hourly average", s.Title, style.AlignLeft)
+		p.Br(float64(s.Title.FontSize) * 2)
+		nti := pdf.GetSensorTableIter(hours)
+		rows, err := hourlyRows(d, period)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			nti.AddRow(row)
+		}
+		p.DrawSensorMergeTable(nti, b.daysPerPage(p, s), 0, s.TableStyle)
+	}
+
+	rows, err := stateRows(d)
+	if err != nil {
+		return err
+	}
+	if len(rows) > 0 {
+		p.AddDirectPage()
+		p.Text("State changes", s.Title, style.AlignLeft)
+		p.Br(float64(s.Title.FontSize) * 2)
+		nti := pdf.GetSensorTableIter([]string{"Time", "State"})
+		for _, row := range rows {
+			nti.AddRow(row)
+		}
+		p.DrawSenStateTable(nti, s.StateTableStyle)
+	}
+
+	excursions, err := d.Rule.Excursions(d.Name, d.readings)
+	if err != nil {
+		return err
+	}
+	if events := alert.Events(excursions); len(events) > 0 {
+		p.AddDirectPage()
+		p.Text("Excursions", s.Title, style.AlignLeft)
+		p.Br(float64(s.Title.FontSize) * 2)
+		ts := s.EventTableStyle
+		if len(ts.Columns) == 0 {
+			ts = style.DefaultEventTableStyle
+		}
+		return events.Draw(p, ts)
+	}
+	return nil
+}
+
+func (b *Builder) chart(p pdf.PDF, d *sensorData, s style.SensorV3ReportStyle) error {
+	tlc := d.hourly.Chart(d.Unit)
+	tlc.Width, tlc.Height = int(p.GetWidth()), 300
+	// 以底色標示超出門檻的事件
+	excursions, err := d.Rule.Excursions(d.Name, d.readings)
+	if err != nil {
+		return err
+	}
+	for _, e := range excursions {
+		r := mychart.TimeRange{Start: e.Start.Unix(), End: e.End.Unix()}
+		if e.Severity == pdf.SeverityLowWarn || e.Severity == pdf.SeverityLowAlarm {
+			r.Color = style.ColorCoolAlert.WithAlpha(0.35)
+		}
+		tlc.Ranges = append(tlc.Ranges, r)
+	}
+	var buf bytes.Buffer
+	if err := tlc.Draw(b.fontMap[s.Content.Font], &buf); errors.Is(err, mychart.ErrNoData) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("report: chart %s: %w", d.Name, err)
+	}
+	p.ImageReader(&buf)
+	p.Br(float64(tlc.Height))
+	return nil
+}
+
+// daysPerPage 合併表格每頁可放的天數
+func (b *Builder) daysPerPage(p pdf.PDF, s style.SensorV3ReportStyle) int {
+	_, lines, rowH := s.TableStyle.MergeLayout(24)
+	n := int((p.GetHeight() - float64(s.Title.FontSize)*2) / (float64(lines*2) * rowH))
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+var hours = func() []string {
+	h := make([]string, 24)
+	for i := range h {
+		h[i] = fmt.Sprintf("%02d", i)
+	}
+	return h
+}()
+
+// hourlyRows 每天一列，第一格為日期，其後為各小時平均值並依規則標示警示等級
+func hourlyRows(d *sensorData, period Period) ([][]pdf.SensorCell, error) {
+	loc := period.location()
+	avg := make([]alert.Reading, 0, len(d.hourly.Buckets))
+	for _, bk := range d.hourly.Buckets {
+		avg = append(avg, alert.Reading{Time: bk.Start, Value: bk.Avg})
+	}
+	var rows [][]pdf.SensorCell
+	for day := stats.Day.Start(period.Start.In(loc)); day.Before(period.End); day = stats.Day.Next(day) {
+		y, m, dd := day.Date()
+		slots := make([]time.Time, len(hours))
+		for h := range slots {
+			slots[h] = time.Date(y, m, dd, h, 0, 0, 0, loc)
+		}
+		cells, err := d.Rule.Cells(avg, slots, formatValue)
+		if err != nil {
+			return nil, err
+		}
+		row := []pdf.SensorCell{{Value: day.Format("01/02"), IsHeader: true}}
+		rows = append(rows, append(row, cells...))
+	}
+	return rows, nil
+}
+
+// stateRows 警示等級改變時的時間及狀態
+func stateRows(d *sensorData) ([][]pdf.SensorCell, error) {
+	sevs, err := d.Rule.Evaluate(d.readings)
+	if err != nil {
+		return nil, err
+	}
+	var rows [][]pdf.SensorCell
+	last := pdf.Severity(-1)
+	for i, sev := range sevs {
+		if sev == last {
+			continue
+		}
+		last = sev
+		rows = append(rows, []pdf.SensorCell{
+			{Value: d.readings[i].Time.Format("2006-01-02 15:04")},
+			{Value: sev.String(), Severity: sev},
+		})
+	}
+	return rows, nil
+}
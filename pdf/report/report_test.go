@@ -0,0 +1,103 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/94peter/export/pdf"
+	"github.com/94peter/export/pdf/alert"
+	"github.com/94peter/export/pdf/style"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func testSensors(start time.Time, days int) []Sensor {
+	var readings []alert.Reading
+	for i := 0; i < days*144; i++ {
+		v := 5 + 4*math.Sin(float64(i)/30)
+		if i/144 == days/2 && i%144 < 12 {
+			v = math.NaN()
+		}
+		readings = append(readings, alert.Reading{Time: start.Add(time.Duration(i) * 10 * time.Minute), Value: v})
+	}
+	return []Sensor{{
+		Name:     "Fridge A",
+		Unit:     "°C",
+		Readings: readings,
+		Rule:     alert.Rule{HighAlarm: alert.Float(8), HighWarn: alert.Float(7), LowAlarm: alert.Float(2)},
+	}}
+}
+
+func Test_Build(t *testing.T) {
+	fontFile := filepath.Join(t.TempDir(), "goregular.ttf")
+	assert.NoError(t, os.WriteFile(fontFile, goregular.TTF, 0o644))
+	b := NewBuilder(map[string]string{"tw-r": fontFile, "tw-m": fontFile})
+
+	loc := time.FixedZone("UTC+8", 8*3600)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, loc)
+	for _, tc := range []struct {
+		name  string
+		build func(context.Context, []Sensor, Period, style.SensorV3ReportStyle) (pdf.PDF, error)
+		end   time.Time
+	}{
+		{"daily", b.BuildDaily, start.AddDate(0, 0, 1)},
+		{"weekly", b.BuildWeekly, start.AddDate(0, 0, 7)},
+		{"monthly", b.BuildMonthly, start.AddDate(0, 1, 0)},
+	} {
+		period := Period{Start: start, End: tc.end}
+		days := int(tc.end.Sub(start).Hours() / 24)
+		p, err := tc.build(context.Background(), testSensors(start, days), period, style.SensorV3Style)
+		if !assert.NoError(t, err, tc.name) {
+			continue
+		}
+		// 封面、摘要、統計表格、明細、每小時平均、狀態變化
+		assert.GreaterOrEqual(t, p.GetPage(), uint8(6), tc.name)
+		var buf bytes.Buffer
+		assert.NoError(t, p.Write(&buf), tc.name)
+	}
+
+	// 月報每天一列，預設樣式每頁 8 列會超出頁面，須依頁面高度換頁：
+	// 每列 4 個感測器，6 及 8 個感測器的統計表格一頁，10 個需要兩頁
+	month := Period{Start: start, End: start.AddDate(0, 1, 0)}
+	pages := map[int]int{}
+	for _, n := range []int{6, 8, 10} {
+		sensors := testSensors(start, 31)
+		for i := 1; i < n; i++ {
+			sensors = append(sensors, Sensor{Name: fmt.Sprintf("Sensor %d", i), Readings: sensors[0].Readings})
+		}
+		p, err := b.BuildMonthly(context.Background(), sensors, month, style.SensorV3Style)
+		assert.NoError(t, err)
+		pages[n] = int(p.GetPage())
+	}
+	assert.Equal(t, pages[8]-pages[6]+1, pages[10]-pages[8])
+
+	ts := *style.DefaultWeeklySensorStyle
+	ts.TableStyle.RowColumnNumber = 0
+	b.TableStyle = &ts
+	_, err := b.BuildWeekly(context.Background(), testSensors(start, 7), Period{Start: start, End: start.AddDate(0, 0, 7)}, style.SensorV3Style)
+	assert.ErrorContains(t, err, "table style: invalid style: TableStyle.RowColumnNumber")
+	b.TableStyle = nil
+
+	sensors := testSensors(start, 7)
+	period := Period{Start: start, End: start.AddDate(0, 0, 7)}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = b.BuildDaily(ctx, sensors, period, style.SensorV3Style)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = b.BuildMonthly(context.Background(), sensors, Period{Start: start, End: start}, style.SensorV3Style)
+	assert.Error(t, err)
+
+	_, err = NewBuilder(nil).BuildWeekly(context.Background(), sensors, period, style.SensorV3Style)
+	assert.ErrorContains(t, err, "fontMap is empty")
+
+	sensors[0].Rule.MaxRate = 1
+	_, err = b.BuildWeekly(context.Background(), sensors, period, style.SensorV3Style)
+	assert.ErrorIs(t, err, alert.ErrInvalidRule)
+}
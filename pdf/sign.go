@@ -0,0 +1,556 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/freetype"
+	"github.com/golang/freetype/truetype"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+var (
+	ErrSignEncrypted    = errors.New("cannot sign an encrypted pdf")
+	ErrUnsupportedKey   = errors.New("unsupported signing key")
+	ErrSignatureTooLong = errors.New("signature exceeds reserved space")
+
+	oidData                 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData           = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidAttrContentType      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidAttrMessageDigest    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidAttrSigningCertV2    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+	oidSHA256               = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidRSAEncryption        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidECDSAWithSHA256      = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	signatureReservedLength = 16384
+	byteRangePlaceholder    = "[0 0000000000 0000000000 0000000000]"
+)
+
+// Signer 簽章用的私鑰與憑證
+type Signer struct {
+	Key         crypto.Signer
+	Certificate *x509.Certificate
+	// 中繼憑證，會一併放入簽章
+	Chain []*x509.Certificate
+}
+
+// LoadPEMSigner 讀取 PEM 格式的憑證(可含憑證鏈)與私鑰
+func LoadPEMSigner(certPEM, keyPEM []byte) (*Signer, error) {
+	s := &Signer{}
+	for block, rest := pem.Decode(certPEM); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if s.Certificate == nil {
+			s.Certificate = cert
+		} else {
+			s.Chain = append(s.Chain, cert)
+		}
+	}
+	if s.Certificate == nil {
+		return nil, errors.New("no certificate found in pem")
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("no private key found in pem")
+	}
+	key, err := parsePrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	s.Key = key
+	return s, nil
+}
+
+// LoadPKCS12Signer 讀取 PKCS#12 (.p12/.pfx) 檔案內容，檔案內的 CA 憑證做為憑證鏈
+func LoadPKCS12Signer(data []byte, password string) (*Signer, error) {
+	key, cert, chain, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+	return &Signer{Key: signer, Certificate: cert, Chain: chain}, nil
+}
+
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+	return signer, nil
+}
+
+// SignatureOption 簽章資訊與可見簽章框
+type SignatureOption struct {
+	Reason      string
+	Location    string
+	ContactInfo string
+	// 零值時使用簽章當下時間
+	SigningTime time.Time
+
+	// 可見簽章框所在頁碼，0 表示不顯示簽章框
+	Page uint8
+	// 簽章框左上角位置及大小
+	X, Y, W, H float64
+	// 簽章框文字使用的字型檔
+	FontFile string
+}
+
+// Sign 對 PDF.Write 輸出的文件加上 PAdES (ETSI.CAdES.detached) 數位簽章，以增量更新輸出至 w
+func Sign(doc []byte, w io.Writer, signer *Signer, opt SignatureOption) error {
+	u, err := parseUpdate(doc)
+	if err != nil {
+		return err
+	}
+	if _, ok := dictGet(u.trailer, "Encrypt"); ok {
+		return ErrSignEncrypted
+	}
+	switch signer.Key.Public().(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+	default:
+		return ErrUnsupportedKey
+	}
+	if opt.SigningTime.IsZero() {
+		opt.SigningTime = time.Now()
+	}
+	rootID, ok := u.trailerRef("Root")
+	if !ok {
+		return fmt.Errorf("%w: trailer without /Root", ErrMalformedPDF)
+	}
+	catalog, err := u.dict(rootID)
+	if err != nil {
+		return err
+	}
+
+	pageNo := int(opt.Page)
+	if pageNo == 0 {
+		pageNo = 1
+	}
+	pageID, pageDict, err := u.page(pageNo)
+	if err != nil {
+		return err
+	}
+
+	sigID := u.alloc()
+	sig := "<<\n/Type /Sig\n/Filter /Adobe.PPKLite\n/SubFilter /ETSI.CAdES.detached\n>>"
+	sig = dictSet(sig, "ByteRange", byteRangePlaceholder)
+	sig = dictSet(sig, "Contents", "<"+strings.Repeat("0", signatureReservedLength*2)+">")
+	sig = dictSet(sig, "M", u.hexString(sigID, []byte(infoDate(opt.SigningTime))))
+	sig = dictSet(sig, "Name", u.textString(sigID, signer.Certificate.Subject.CommonName))
+	for _, kv := range [][2]string{
+		{"Reason", opt.Reason},
+		{"Location", opt.Location},
+		{"ContactInfo", opt.ContactInfo},
+	} {
+		if kv[1] != "" {
+			sig = dictSet(sig, kv[0], u.textString(sigID, kv[1]))
+		}
+	}
+	u.set(sigID, []byte(sig))
+
+	fieldID := u.alloc()
+	form, fields, acroformID, err := u.acroForm(catalog)
+	if err != nil {
+		return err
+	}
+	field := "<<\n/Type /Annot\n/Subtype /Widget\n/FT /Sig\n/F 132\n>>"
+	field = dictSet(field, "T", u.textString(fieldID, fmt.Sprintf("Signature%d", len(fields)+1)))
+	field = dictSet(field, "V", formatRef(sigID))
+	field = dictSet(field, "P", formatRef(pageID))
+	if opt.Page == 0 {
+		field = dictSet(field, "Rect", "[0 0 0 0]")
+	} else {
+		pageH, err := u.pageHeight(pageDict)
+		if err != nil {
+			return err
+		}
+		field = dictSet(field, "Rect", fmt.Sprintf("[%.2f %.2f %.2f %.2f]", opt.X, pageH-opt.Y-opt.H, opt.X+opt.W, pageH-opt.Y))
+		apID, err := u.signatureAppearance(signer, &opt)
+		if err != nil {
+			return err
+		}
+		field = dictSet(field, "AP", fmt.Sprintf("<< /N %s >>", formatRef(apID)))
+	}
+	u.set(fieldID, []byte(field))
+
+	annots, err := u.appendArray(pageDict, "Annots", formatRef(fieldID))
+	if err != nil {
+		return err
+	}
+	if annots != "" {
+		u.set(pageID, []byte(annots))
+	}
+	fields = append(fields, formatRef(fieldID))
+	form = dictSet(form, "Fields", "["+strings.Join(fields, " ")+"]")
+	u.set(acroformID, []byte(dictSet(form, "SigFlags", "3")))
+	u.set(rootID, []byte(dictSet(catalog, "AcroForm", formatRef(acroformID))))
+
+	var buf bytes.Buffer
+	if err = u.write(&buf); err != nil {
+		return err
+	}
+	out := buf.Bytes()
+	return signByteRange(out, w, len(doc), signer)
+}
+
+// 計算 ByteRange 並填入 CMS 簽章
+func signByteRange(out []byte, w io.Writer, from int, signer *Signer) error {
+	contentsKey := []byte("/Contents <" + strings.Repeat("0", 32))
+	start := bytes.Index(out[from:], contentsKey)
+	if start < 0 {
+		return fmt.Errorf("%w: signature placeholder not found", ErrMalformedPDF)
+	}
+	start += from + len("/Contents ")
+	end := start + signatureReservedLength*2 + 2
+	byteRange := fmt.Sprintf("[0 %d %d %d]", start, end, len(out)-end)
+	brStart := bytes.Index(out[from:], []byte(byteRangePlaceholder))
+	if brStart < 0 {
+		return fmt.Errorf("%w: byte range placeholder not found", ErrMalformedPDF)
+	}
+	brStart += from
+	copy(out[brStart:], byteRange+strings.Repeat(" ", len(byteRangePlaceholder)-len(byteRange)))
+
+	h := sha256.New()
+	h.Write(out[:start])
+	h.Write(out[end:])
+	signature, err := signer.cms(h.Sum(nil))
+	if err != nil {
+		return err
+	}
+	if len(signature) > signatureReservedLength {
+		return ErrSignatureTooLong
+	}
+	hex.Encode(out[start+1:], signature)
+	_, err = w.Write(out)
+	return err
+}
+
+// page 依頁碼(從 1 開始)找出頁面物件
+func (u *pdfUpdate) page(pageNo int) (int, string, error) {
+	rootID, _ := u.trailerRef("Root")
+	catalog, err := u.dict(rootID)
+	if err != nil {
+		return 0, "", err
+	}
+	pagesRef, _ := dictGet(catalog, "Pages")
+	pagesID, ok := parseRef(pagesRef)
+	if !ok {
+		return 0, "", fmt.Errorf("%w: catalog without /Pages", ErrMalformedPDF)
+	}
+	count := 0
+	var walk func(id int) (int, string, error)
+	walk = func(id int) (int, string, error) {
+		d, err := u.dict(id)
+		if err != nil {
+			return 0, "", err
+		}
+		if t, _ := dictGet(d, "Type"); t == "/Page" {
+			count++
+			if count == pageNo {
+				return id, d, nil
+			}
+			return 0, "", nil
+		}
+		kids, _ := dictGet(d, "Kids")
+		for _, ref := range refRegexAll.FindAllStringSubmatch(kids, -1) {
+			kid, _ := strconv.Atoi(ref[1])
+			if pid, pd, err := walk(kid); err != nil || pid != 0 {
+				return pid, pd, err
+			}
+		}
+		return 0, "", nil
+	}
+	id, d, err := walk(pagesID)
+	if err == nil && id == 0 {
+		err = fmt.Errorf("page %d not found", pageNo)
+	}
+	return id, d, err
+}
+
+// pageHeight 取得頁面高度，頁面未設定 MediaBox 時使用上層設定
+func (u *pdfUpdate) pageHeight(pageDict string) (float64, error) {
+	d := pageDict
+	for {
+		if box, ok := dictGet(d, "MediaBox"); ok {
+			f := strings.Fields(strings.Trim(box, "[]"))
+			if len(f) != 4 {
+				break
+			}
+			y1, _ := strconv.ParseFloat(f[1], 64)
+			y2, _ := strconv.ParseFloat(f[3], 64)
+			return y2 - y1, nil
+		}
+		parent, ok := dictGet(d, "Parent")
+		if !ok {
+			break
+		}
+		id, _ := parseRef(parent)
+		var err error
+		if d, err = u.dict(id); err != nil {
+			return 0, err
+		}
+	}
+	return 0, fmt.Errorf("%w: page without /MediaBox", ErrMalformedPDF)
+}
+
+// acroForm 取得既有 AcroForm 字典(保留 /DA、/DR 等設定)及欄位，回傳要寫入的 AcroForm 物件編號
+func (u *pdfUpdate) acroForm(catalog string) (string, []string, int, error) {
+	v, ok := dictGet(catalog, "AcroForm")
+	if !ok {
+		return "<<\n>>", nil, u.alloc(), nil
+	}
+	form := v
+	id, isRef := parseRef(v)
+	if isRef {
+		var err error
+		if form, err = u.dict(id); err != nil {
+			return "", nil, 0, err
+		}
+	} else {
+		id = u.alloc()
+	}
+	fields, _ := dictGet(form, "Fields")
+	return form, refRegexAll.FindAllString(fields, -1), id, nil
+}
+
+// appendArray 在字典的陣列加入元素，陣列為間接物件時直接修改該物件並回傳空字串
+func (u *pdfUpdate) appendArray(dict string, key string, item string) (string, error) {
+	v, ok := dictGet(dict, key)
+	if !ok {
+		return dictSet(dict, key, "["+item+"]"), nil
+	}
+	if id, isRef := parseRef(v); isRef {
+		arr, err := u.object(id)
+		if err != nil {
+			return "", err
+		}
+		s := strings.TrimSpace(string(arr))
+		u.set(id, []byte(s[:len(s)-1]+" "+item+"]"))
+		return "", nil
+	}
+	return dictSet(dict, key, v[:len(v)-1]+" "+item+"]"), nil
+}
+
+// signatureAppearance 產生可見簽章框，文字以指定字型繪製成影像
+func (u *pdfUpdate) signatureAppearance(signer *Signer, opt *SignatureOption) (int, error) {
+	fontBytes, err := os.ReadFile(opt.FontFile)
+	if err != nil {
+		return 0, err
+	}
+	font, err := truetype.Parse(fontBytes)
+	if err != nil {
+		return 0, err
+	}
+	lines := []string{
+		"Digitally signed by " + signer.Certificate.Subject.CommonName,
+		"Date: " + opt.SigningTime.Format("2006-01-02 15:04:05 -07:00"),
+	}
+	if opt.Reason != "" {
+		lines = append(lines, "Reason: "+opt.Reason)
+	}
+	if opt.Location != "" {
+		lines = append(lines, "Location: "+opt.Location)
+	}
+
+	const scale = 4.0
+	imgW, imgH := int(opt.W*scale), int(opt.H*scale)
+	img := image.NewRGBA(image.Rect(0, 0, imgW, imgH))
+	draw.Draw(img, img.Bounds(), image.Black, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(int(scale), int(scale), imgW-int(scale), imgH-int(scale)), image.White, image.Point{}, draw.Src)
+
+	fontSize := opt.H * scale / float64(len(lines)) * 0.6
+	ctx := freetype.NewContext()
+	ctx.SetDPI(72)
+	ctx.SetFont(font)
+	ctx.SetFontSize(fontSize)
+	ctx.SetClip(img.Bounds())
+	ctx.SetDst(img)
+	ctx.SetSrc(image.NewUniform(color.Black))
+	lineH := opt.H * scale / float64(len(lines))
+	for i, l := range lines {
+		pt := freetype.Pt(int(scale*4), int(lineH*float64(i)+lineH*0.75))
+		if _, err = ctx.DrawString(l, pt); err != nil {
+			return 0, err
+		}
+	}
+
+	var rgb bytes.Buffer
+	zw := zlib.NewWriter(&rgb)
+	for y := 0; y < imgH; y++ {
+		for x := 0; x < imgW; x++ {
+			c := img.RGBAAt(x, y)
+			zw.Write([]byte{c.R, c.G, c.B})
+		}
+	}
+	zw.Close()
+
+	imgID := u.alloc()
+	u.set(imgID, u.stream(imgID, fmt.Sprintf(
+		"<<\n/Type /XObject\n/Subtype /Image\n/Width %d\n/Height %d\n/ColorSpace /DeviceRGB\n/BitsPerComponent 8\n/Filter /FlateDecode\n>>",
+		imgW, imgH), rgb.Bytes()))
+	apID := u.alloc()
+	u.set(apID, u.stream(apID, fmt.Sprintf(
+		"<<\n/Type /XObject\n/Subtype /Form\n/BBox [0 0 %.2f %.2f]\n/Resources << /XObject << /Img %s >> >>\n>>",
+		opt.W, opt.H, formatRef(imgID)),
+		[]byte(fmt.Sprintf("q %.2f 0 0 %.2f 0 0 cm /Img Do Q", opt.W, opt.H))))
+	return apID, nil
+}
+
+type algorithmIdentifier struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue `asn1:"optional"`
+}
+
+type issuerAndSerial struct {
+	Issuer asn1.RawValue
+	Serial *big.Int
+}
+
+type signerInfo struct {
+	Version            int
+	SID                issuerAndSerial
+	DigestAlgorithm    algorithmIdentifier
+	SignedAttrs        asn1.RawValue
+	SignatureAlgorithm algorithmIdentifier
+	Signature          []byte
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []algorithmIdentifier `asn1:"set"`
+	EncapContentInfo struct {
+		ContentType asn1.ObjectIdentifier
+	}
+	Certificates asn1.RawValue
+	SignerInfos  []signerInfo `asn1:"set"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+type essCertIDv2 struct {
+	CertHash []byte
+}
+
+// cms 產生 detached CMS SignedData
+func (s *Signer) cms(digest []byte) ([]byte, error) {
+	certHash := sha256.Sum256(s.Certificate.Raw)
+	signingCert, err := asn1.Marshal(struct {
+		Certs []essCertIDv2
+	}{[]essCertIDv2{{CertHash: certHash[:]}}})
+	if err != nil {
+		return nil, err
+	}
+	contentType, _ := asn1.Marshal(oidData)
+	messageDigest, _ := asn1.Marshal(digest)
+	var attrs [][]byte
+	for _, a := range []struct {
+		oid   asn1.ObjectIdentifier
+		value []byte
+	}{
+		{oidAttrContentType, contentType},
+		{oidAttrMessageDigest, messageDigest},
+		{oidAttrSigningCertV2, signingCert},
+	} {
+		b, err := asn1.Marshal(attribute{
+			Type:   a.oid,
+			Values: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: a.value},
+		})
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, b)
+	}
+	// DER 的 SET OF 需依編碼排序
+	sort.Slice(attrs, func(i, j int) bool { return bytes.Compare(attrs[i], attrs[j]) < 0 })
+	attrBytes := bytes.Join(attrs, nil)
+
+	signedAttrs, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: attrBytes})
+	if err != nil {
+		return nil, err
+	}
+	attrDigest := sha256.Sum256(signedAttrs)
+	signature, err := s.Key.Sign(rand.Reader, attrDigest[:], crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	sigAlg := algorithmIdentifier{Algorithm: oidECDSAWithSHA256}
+	if _, ok := s.Key.Public().(*rsa.PublicKey); ok {
+		sigAlg = algorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1.NullRawValue}
+	}
+
+	var certs []byte
+	for _, c := range append([]*x509.Certificate{s.Certificate}, s.Chain...) {
+		certs = append(certs, c.Raw...)
+	}
+	sd := signedData{
+		Version:          1,
+		DigestAlgorithms: []algorithmIdentifier{{Algorithm: oidSHA256}},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certs},
+		SignerInfos: []signerInfo{{
+			Version: 1,
+			SID: issuerAndSerial{
+				Issuer: asn1.RawValue{FullBytes: s.Certificate.RawIssuer},
+				Serial: s.Certificate.SerialNumber,
+			},
+			DigestAlgorithm:    algorithmIdentifier{Algorithm: oidSHA256},
+			SignedAttrs:        asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: attrBytes},
+			SignatureAlgorithm: sigAlg,
+			Signature:          signature,
+		}},
+	}
+	sd.EncapContentInfo.ContentType = oidData
+	sdBytes, err := asn1.Marshal(sd)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sdBytes},
+	})
+}
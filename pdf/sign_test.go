@@ -0,0 +1,270 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/font/gofont/goregular"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+func selfSignedPEM(t *testing.T, key crypto.Signer) ([]byte, []byte) {
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "QA Signer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer})
+}
+
+func testDocument(t *testing.T) []byte {
+	p := NewPDFv2(nil, 20, 20, 20, 20)
+	p.AddDirectPage()
+	p.LineXY(1, 20, 20, 200, 200)
+	var buf bytes.Buffer
+	assert.NoError(t, p.Write(&buf))
+	return buf.Bytes()
+}
+
+// verifySignature 依 ByteRange 驗證最後一個簽章
+func verifySignature(t *testing.T, signed []byte, cert *x509.Certificate) {
+	brs := regexp.MustCompile(`/ByteRange \[0 (\d+) (\d+) (\d+)\]`).FindAllSubmatch(signed, -1)
+	if !assert.NotEmpty(t, brs) {
+		return
+	}
+	br := brs[len(brs)-1]
+	start, _ := strconv.Atoi(string(br[1]))
+	end, _ := strconv.Atoi(string(br[2]))
+	length, _ := strconv.Atoi(string(br[3]))
+	assert.Equal(t, len(signed), end+length)
+
+	h := sha256.New()
+	h.Write(signed[:start])
+	h.Write(signed[end:])
+	digest := h.Sum(nil)
+
+	// 預留空間補零，asn1.Unmarshal 會忽略多餘的位元組
+	der, err := hex.DecodeString(string(signed[start+1 : end-1]))
+	assert.NoError(t, err)
+	var ci contentInfo
+	_, err = asn1.Unmarshal(der, &ci)
+	assert.NoError(t, err)
+	assert.True(t, ci.ContentType.Equal(oidSignedData))
+	var sd signedData
+	_, err = asn1.Unmarshal(ci.Content.Bytes, &sd)
+	assert.NoError(t, err)
+	if !assert.Len(t, sd.SignerInfos, 1) {
+		return
+	}
+	si := sd.SignerInfos[0]
+
+	rest := si.SignedAttrs.Bytes
+	var found bool
+	for len(rest) > 0 {
+		var a attribute
+		rest, err = asn1.Unmarshal(rest, &a)
+		assert.NoError(t, err)
+		if a.Type.Equal(oidAttrMessageDigest) {
+			var md []byte
+			_, err = asn1.Unmarshal(a.Values.Bytes, &md)
+			assert.NoError(t, err)
+			assert.Equal(t, digest, md)
+			found = true
+		}
+	}
+	assert.True(t, found)
+
+	signedAttrs, _ := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: si.SignedAttrs.Bytes})
+	assert.NoError(t, cert.CheckSignature(signatureAlgorithm(cert), signedAttrs, si.Signature))
+}
+
+func signatureAlgorithm(cert *x509.Certificate) x509.SignatureAlgorithm {
+	if _, ok := cert.PublicKey.(*rsa.PublicKey); ok {
+		return x509.SHA256WithRSA
+	}
+	return x509.ECDSAWithSHA256
+}
+
+func Test_SignECDSA(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	certPEM, keyPEM := selfSignedPEM(t, key)
+	signer, err := LoadPEMSigner(certPEM, keyPEM)
+	assert.NoError(t, err)
+
+	doc := testDocument(t)
+	var out bytes.Buffer
+	err = Sign(doc, &out, signer, SignatureOption{Reason: "GDP record", Location: "Taipei"})
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(out.Bytes(), doc))
+	verifySignature(t, out.Bytes(), signer.Certificate)
+}
+
+func Test_SignVisibleTwice(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	certPEM, keyPEM := selfSignedPEM(t, key)
+	signer, err := LoadPEMSigner(certPEM, keyPEM)
+	assert.NoError(t, err)
+	fontFile := filepath.Join(t.TempDir(), "goregular.ttf")
+	assert.NoError(t, os.WriteFile(fontFile, goregular.TTF, 0o644))
+
+	opt := SignatureOption{
+		Reason:   "Part 11 approval",
+		Page:     1,
+		X:        350,
+		Y:        700,
+		W:        200,
+		H:        60,
+		FontFile: fontFile,
+	}
+	var first, second bytes.Buffer
+	assert.NoError(t, Sign(testDocument(t), &first, signer, opt))
+	verifySignature(t, first.Bytes(), signer.Certificate)
+
+	opt.Y = 620
+	assert.NoError(t, Sign(first.Bytes(), &second, signer, opt))
+	verifySignature(t, second.Bytes(), signer.Certificate)
+	assert.Regexp(t, `/Fields \[\d+ 0 R \d+ 0 R\]`, second.String())
+}
+
+func Test_SignEncrypted(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	certPEM, keyPEM := selfSignedPEM(t, key)
+	signer, _ := LoadPEMSigner(certPEM, keyPEM)
+
+	p := NewPDFv2(nil, 20, 20, 20, 20, WithProtection(Protection{UserPassword: "u"}))
+	p.AddDirectPage()
+	var doc bytes.Buffer
+	assert.NoError(t, p.Write(&doc))
+	assert.ErrorIs(t, Sign(doc.Bytes(), &bytes.Buffer{}, signer, SignatureOption{}), ErrSignEncrypted)
+}
+
+func Test_SignPKCS12Chain(t *testing.T) {
+	newCert := func(serial int64, cn string, parent *x509.Certificate, parentKey crypto.Signer, key crypto.Signer, ca bool) *x509.Certificate {
+		tmpl := &x509.Certificate{
+			SerialNumber:          big.NewInt(serial),
+			Subject:               pkix.Name{CommonName: cn},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+			BasicConstraintsValid: true,
+			IsCA:                  ca,
+		}
+		if parent == nil {
+			parent, parentKey = tmpl, key
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, key.Public(), parentKey)
+		assert.NoError(t, err)
+		cert, err := x509.ParseCertificate(der)
+		assert.NoError(t, err)
+		return cert
+	}
+	rootKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	interKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	leafKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	root := newCert(1, "QA Root", nil, nil, rootKey, true)
+	inter := newCert(2, "QA Intermediate", root, rootKey, interKey, true)
+	leaf := newCert(3, "QA Signer", inter, interKey, leafKey, false)
+
+	p12, err := pkcs12.Modern.Encode(leafKey, leaf, []*x509.Certificate{inter}, "secret")
+	assert.NoError(t, err)
+	_, err = LoadPKCS12Signer(p12, "wrong")
+	assert.Error(t, err)
+	signer, err := LoadPKCS12Signer(p12, "secret")
+	assert.NoError(t, err)
+	assert.Len(t, signer.Chain, 1)
+
+	var out bytes.Buffer
+	assert.NoError(t, Sign(testDocument(t), &out, signer, SignatureOption{}))
+	verifySignature(t, out.Bytes(), leaf)
+
+	// 簽章內的憑證須包含中繼憑證，才能驗證到根憑證
+	br := regexp.MustCompile(`/ByteRange \[0 (\d+) (\d+) \d+\]`).FindSubmatch(out.Bytes())
+	start, _ := strconv.Atoi(string(br[1]))
+	end, _ := strconv.Atoi(string(br[2]))
+	der, err := hex.DecodeString(string(out.Bytes()[start+1 : end-1]))
+	assert.NoError(t, err)
+	var ci contentInfo
+	_, err = asn1.Unmarshal(der, &ci)
+	assert.NoError(t, err)
+	var sd signedData
+	_, err = asn1.Unmarshal(ci.Content.Bytes, &sd)
+	assert.NoError(t, err)
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	assert.NoError(t, err)
+	if assert.Len(t, certs, 2) {
+		assert.True(t, certs[0].Equal(leaf))
+		assert.True(t, certs[1].Equal(inter))
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	inters := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		inters.AddCert(c)
+	}
+	_, err = certs[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: inters, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+	assert.NoError(t, err)
+}
+
+func Test_SignKeepsAcroForm(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	certPEM, keyPEM := selfSignedPEM(t, key)
+	signer, _ := LoadPEMSigner(certPEM, keyPEM)
+
+	// 既有表單的預設外觀及資源需保留
+	doc := testDocument(t)
+	u, err := parseUpdate(doc)
+	assert.NoError(t, err)
+	rootID, _ := u.trailerRef("Root")
+	catalog, err := u.dict(rootID)
+	assert.NoError(t, err)
+	u.set(rootID, []byte(dictSet(catalog, "AcroForm", "<< /Fields [] /DA (/Helv 0 Tf 0 g) /DR << /Font << /Helv 1 0 R >> >> >>")))
+	var buf bytes.Buffer
+	assert.NoError(t, u.write(&buf))
+
+	var out bytes.Buffer
+	assert.NoError(t, Sign(buf.Bytes(), &out, signer, SignatureOption{}))
+	verifySignature(t, out.Bytes(), signer.Certificate)
+
+	u, err = parseUpdate(out.Bytes())
+	assert.NoError(t, err)
+	catalog, err = u.dict(rootID)
+	assert.NoError(t, err)
+	ref, _ := dictGet(catalog, "AcroForm")
+	formID, ok := parseRef(ref)
+	assert.True(t, ok)
+	form, err := u.dict(formID)
+	assert.NoError(t, err)
+	da, _ := dictGet(form, "DA")
+	assert.Equal(t, "(/Helv 0 Tf 0 g)", da)
+	dr, _ := dictGet(form, "DR")
+	assert.Contains(t, dr, "/Helv 1 0 R")
+	sigFlags, _ := dictGet(form, "SigFlags")
+	assert.Equal(t, "3", sigFlags)
+	fields, _ := dictGet(form, "Fields")
+	assert.Regexp(t, `^\[\d+ 0 R\]$`, fields)
+}
@@ -0,0 +1,282 @@
+// Package stats 將原始讀值依時間分組並計算統計值，結果可直接用於 TimeValueTable 及 TimeLineChart
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/94peter/export/pdf"
+	"github.com/94peter/export/pdf/alert"
+	"github.com/94peter/export/pdf/mychart"
+	"github.com/94peter/export/pdf/style"
+)
+
+// Period 分組的時間單位
+type Period int
+
+const (
+	Hour Period = iota
+	Day
+	// 週一開始
+	Week
+	Month
+)
+
+// Start 取得 t 所在分組的開始時間
+func (p Period) Start(t time.Time) time.Time {
+	y, m, d := t.Date()
+	loc := t.Location()
+	switch p {
+	case Hour:
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, loc)
+	case Week:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, loc)
+	case Month:
+		return time.Date(y, m, 1, 0, 0, 0, 0, loc)
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+// Next 取得 start 的下一個分組開始時間，以日曆計算，日光節約時間的日長不一定為 24 小時
+func (p Period) Next(start time.Time) time.Time {
+	switch p {
+	case Hour:
+		return start.Add(time.Hour)
+	case Week:
+		return start.AddDate(0, 0, 7)
+	case Month:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// 預設的時間標籤格式
+func (p Period) layout() string {
+	switch p {
+	case Hour:
+		return "15"
+	case Week:
+		return "01/02"
+	case Month:
+		return "01"
+	}
+	return "02"
+}
+
+// DefaultActivationEnergy MKT 的活化能除以氣體常數 (ΔH/R)，83.144 kJ/mol 對應 10000 K
+const DefaultActivationEnergy = 10000.0
+
+// Options 分組及統計的設定
+type Options struct {
+	// 分組使用的時區，nil 為 UTC
+	Location *time.Location
+	// 預期的取樣間隔，設定時以此計算缺少的筆數，並作為每筆讀值代表的時間
+	Interval time.Duration
+	// 上下限，nil 表示不計算超出的時間
+	Upper, Lower *float64
+	// MKT 的 ΔH/R (K)，0 為 DefaultActivationEnergy
+	ActivationEnergy float64
+}
+
+// Bucket 一個分組的統計值，沒有讀值時 Max、Min、Avg、StdDev、MKT 為 NaN
+type Bucket struct {
+	Start, End time.Time
+	Count      int
+	// 缺少的筆數，包含值為 NaN 的讀值
+	Missing int
+	Max     float64
+	Min     float64
+	Avg     float64
+	// 母體標準差
+	StdDev float64
+	// 平均動力學溫度 (°C)
+	MKT        float64
+	AboveUpper time.Duration
+	BelowLower time.Duration
+}
+
+// Availability 有讀值的比例 (0-1)，未設定 Interval 時只以值為 NaN 的讀值計算缺少的筆數
+func (b Bucket) Availability() float64 {
+	if b.Count+b.Missing == 0 {
+		return 0
+	}
+	return float64(b.Count) / float64(b.Count+b.Missing)
+}
+
+// Series 分組統計的結果
+type Series struct {
+	Period Period
+	// 分組使用的時區，Chart 以此標示刻度
+	Location *time.Location
+	Upper    *float64
+	Lower    *float64
+	Buckets  []Bucket
+
+	activationEnergy float64
+}
+
+// Compute 依 period 將讀值分組，從第一筆讀值所在的分組到最後一筆所在的分組，沒有讀值的分組也會列出
+func Compute(readings []alert.Reading, period Period, opt Options) *Series {
+	loc := opt.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	ea := opt.ActivationEnergy
+	if ea == 0 {
+		ea = DefaultActivationEnergy
+	}
+	rs := make([]alert.Reading, len(readings))
+	copy(rs, readings)
+	sort.SliceStable(rs, func(i, j int) bool { return rs[i].Time.Before(rs[j].Time) })
+
+	s := &Series{Period: period, Location: loc, Upper: opt.Upper, Lower: opt.Lower, activationEnergy: ea}
+	if len(rs) == 0 {
+		return s
+	}
+	last := period.Start(rs[len(rs)-1].Time.In(loc))
+	i := 0
+	for start := period.Start(rs[0].Time.In(loc)); !start.After(last); start = period.Next(start) {
+		b := Bucket{Start: start, End: period.Next(start)}
+		j := i
+		for j < len(rs) && rs[j].Time.Before(b.End) {
+			j++
+		}
+		b.compute(rs[i:j], opt, ea)
+		s.Buckets = append(s.Buckets, b)
+		i = j
+	}
+	return s
+}
+
+func (b *Bucket) compute(rs []alert.Reading, opt Options, ea float64) {
+	b.Max, b.Min = math.Inf(-1), math.Inf(1)
+	var sum, sumSq, arrhenius float64
+	for k, r := range rs {
+		if math.IsNaN(r.Value) {
+			b.Missing++
+			continue
+		}
+		v := r.Value
+		b.Count++
+		sum += v
+		sumSq += v * v
+		arrhenius += math.Exp(-ea / (v + 273.15))
+		b.Max = math.Max(b.Max, v)
+		b.Min = math.Min(b.Min, v)
+
+		d := opt.Interval
+		if d == 0 && k+1 < len(rs) {
+			d = rs[k+1].Time.Sub(r.Time)
+		}
+		if opt.Upper != nil && v > *opt.Upper {
+			b.AboveUpper += d
+		}
+		if opt.Lower != nil && v < *opt.Lower {
+			b.BelowLower += d
+		}
+	}
+	if opt.Interval > 0 {
+		if expected := int(b.End.Sub(b.Start) / opt.Interval); expected > b.Count+b.Missing {
+			b.Missing = expected - b.Count
+		}
+	}
+	if b.Count == 0 {
+		b.Max, b.Min, b.Avg, b.StdDev, b.MKT = math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN()
+		return
+	}
+	n := float64(b.Count)
+	b.Avg = sum / n
+	b.StdDev = math.Sqrt(math.Max(0, sumSq/n-b.Avg*b.Avg))
+	b.MKT = -ea/math.Log(arrhenius/n) - 273.15
+}
+
+// Total 合併所有分組，Start 及 End 為第一個及最後一個分組的範圍
+func (s *Series) Total() Bucket {
+	t := Bucket{Max: math.Inf(-1), Min: math.Inf(1)}
+	if len(s.Buckets) > 0 {
+		t.Start, t.End = s.Buckets[0].Start, s.Buckets[len(s.Buckets)-1].End
+	}
+	var sum, sumSq, arrhenius float64
+	for _, b := range s.Buckets {
+		t.Missing += b.Missing
+		t.AboveUpper += b.AboveUpper
+		t.BelowLower += b.BelowLower
+		if b.Count == 0 {
+			continue
+		}
+		n := float64(b.Count)
+		t.Count += b.Count
+		t.Max, t.Min = math.Max(t.Max, b.Max), math.Min(t.Min, b.Min)
+		sum += b.Avg * n
+		sumSq += (b.StdDev*b.StdDev + b.Avg*b.Avg) * n
+		arrhenius += math.Exp(-s.activationEnergy/(b.MKT+273.15)) * n
+	}
+	if t.Count == 0 {
+		t.Max, t.Min, t.Avg, t.StdDev, t.MKT = math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN()
+		return t
+	}
+	n := float64(t.Count)
+	t.Avg = sum / n
+	t.StdDev = math.Sqrt(math.Max(0, sumSq/n-t.Avg*t.Avg))
+	t.MKT = -s.activationEnergy/math.Log(arrhenius/n) - 273.15
+	return t
+}
+
+// TableIter 轉為 TimeValueTable 的資料，每列為 時間、最大、平均、最小，略過沒有讀值的分組。
+// layout 為空字串時依分組單位使用預設格式
+func (s *Series) TableIter(header, layout string) *pdf.TableValueAryIter {
+	if layout == "" {
+		layout = s.Period.layout()
+	}
+	var data []pdf.TimeValueAry
+	for _, b := range s.Buckets {
+		if b.Count == 0 {
+			continue
+		}
+		data = append(data, pdf.TimeValueAry{
+			Time:   b.Start.Format(layout),
+			Values: []float64{b.Max, b.Avg, b.Min},
+		})
+	}
+	return pdf.GetTableValueAryIter(header, data)
+}
+
+// Chart 轉為最大、平均、最小三條線的 TimeLineChart，時間為分組開始時間，上下限及時區沿用 Options。
+// 沒有讀值的分組不列出，並以最長的分組長度為取樣間隔標示為資料中斷，月份等長度不一的分組不會誤判
+func (s *Series) Chart(name string) *mychart.TimeLineChart {
+	tlc := &mychart.TimeLineChart{
+		YAxisName:    name,
+		NoUpperLower: s.Upper == nil || s.Lower == nil,
+		Location:     s.Location,
+	}
+	for _, b := range s.Buckets {
+		if d := b.End.Sub(b.Start); d > tlc.Interval {
+			tlc.Interval = d
+		}
+	}
+	if s.Upper != nil {
+		tlc.UpperValue = *s.Upper
+	}
+	if s.Lower != nil {
+		tlc.LowerValue = *s.Lower
+	}
+	lines := []mychart.TimeLine{
+		{Name: "Max", Data: map[int64]float64{}, Color: style.ColorMax},
+		{Name: "Avg", Data: map[int64]float64{}, Color: style.ColorAvg},
+		{Name: "Min", Data: map[int64]float64{}, Color: style.ColorMin},
+	}
+	for _, b := range s.Buckets {
+		if b.Count == 0 {
+			continue
+		}
+		ts := b.Start.Unix()
+		tlc.TimestampList = append(tlc.TimestampList, ts)
+		lines[0].Data[ts] = b.Max
+		lines[1].Data[ts] = b.Avg
+		lines[2].Data[ts] = b.Min
+	}
+	tlc.TimeData = lines
+	return tlc
+}
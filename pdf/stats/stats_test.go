@@ -0,0 +1,90 @@
+package stats
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/94peter/export/pdf"
+	"github.com/94peter/export/pdf/alert"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Compute(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	// 台灣時間 1/1 22:00 起每 30 分鐘一筆，共 8 筆
+	start := time.Date(2024, 1, 1, 14, 0, 0, 0, time.UTC)
+	values := []float64{2, 4, 6, math.NaN(), 9, 10, 4, 5}
+	readings := make([]alert.Reading, len(values))
+	for i, v := range values {
+		readings[i] = alert.Reading{Time: start.Add(time.Duration(i) * 30 * time.Minute), Value: v}
+	}
+	s := Compute(readings, Day, Options{
+		Location: loc,
+		Interval: 30 * time.Minute,
+		Upper:    alert.Float(8),
+		Lower:    alert.Float(3),
+	})
+	assert.Len(t, s.Buckets, 2)
+
+	b := s.Buckets[0]
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, loc), b.Start)
+	assert.Equal(t, 3, b.Count)
+	assert.Equal(t, 48-3, b.Missing)
+	assert.Equal(t, []float64{6, 2, 4}, []float64{b.Max, b.Min, b.Avg})
+	assert.InDelta(t, math.Sqrt(8.0/3), b.StdDev, 1e-9)
+	assert.Equal(t, 30*time.Minute, b.BelowLower)
+	assert.InDelta(t, 3.0/48, b.Availability(), 1e-9)
+
+	b = s.Buckets[1]
+	assert.Equal(t, 4, b.Count)
+	assert.Equal(t, time.Hour, b.AboveUpper)
+	// MKT 偏向高溫，介於平均與最大之間
+	assert.Greater(t, b.MKT, b.Avg)
+	assert.Less(t, b.MKT, b.Max)
+
+	var tva pdf.TimeValueAry
+	iter := s.TableIter("2024/01", "")
+	assert.True(t, iter.Next(&tva))
+	assert.Equal(t, pdf.TimeValueAry{Time: "01", Values: []float64{6, 4, 2}}, tva)
+
+	tlc := s.Chart("°C")
+	assert.Len(t, tlc.TimestampList, 2)
+	assert.Equal(t, 10.0, tlc.TimeData[0].Data[s.Buckets[1].Start.Unix()])
+	assert.Equal(t, 8.0, tlc.UpperValue)
+}
+
+func Test_ChartLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, loc)
+	readings := []alert.Reading{{Time: start, Value: 1}, {Time: start.AddDate(0, 0, 1), Value: 2}}
+	tlc := Compute(readings, Day, Options{Location: loc}).Chart("°C")
+	assert.Equal(t, loc, tlc.Location)
+	// 分組開始時間在圖表時區為當地零時，而非前一天 16:00
+	for _, ts := range tlc.TimestampList {
+		assert.Equal(t, "00:00", time.Unix(ts, 0).In(tlc.Location).Format("15:04"))
+	}
+	assert.Equal(t, time.UTC, Compute(readings, Day, Options{}).Chart("°C").Location)
+}
+
+func Test_ChartMonthInterval(t *testing.T) {
+	// 第一個分組為二月，間隔須以最長的月份計算
+	start := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	var readings []alert.Reading
+	for _, m := range []int{0, 1, 2, 3, 5} {
+		readings = append(readings, alert.Reading{Time: start.AddDate(0, m, 10), Value: float64(m)})
+	}
+	s := Compute(readings, Month, Options{})
+	tlc := s.Chart("°C")
+	assert.Equal(t, 31*24*time.Hour, tlc.Interval)
+	// 只有缺少的六月視為中斷
+	assert.Equal(t, [][2]int64{{start.AddDate(0, 3, 0).Unix(), start.AddDate(0, 5, 0).Unix()}}, tlc.Gaps())
+}
+
+func Test_PeriodStart(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	tm := time.Date(2024, 2, 29, 13, 45, 0, 0, loc)
+	assert.Equal(t, time.Date(2024, 2, 29, 13, 0, 0, 0, loc), Hour.Start(tm))
+	assert.Equal(t, time.Date(2024, 2, 26, 0, 0, 0, 0, loc), Week.Start(tm))
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, loc), Month.Next(Month.Start(tm)))
+}
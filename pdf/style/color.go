@@ -1,7 +1,48 @@
 package style
 
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Color A 為 0 且 HasAlpha 為 false 時視為未設定，與 255 相同為不透明
 type Color struct {
 	R, G, B, A uint8
+	// A 已設定，A 為 0 時表示完全透明
+	HasAlpha bool
+	// 印刷用 CMYK，HasCMYK 時 PDF 以 CMYK 輸出，RGB 供圖表等其他輸出使用
+	CMYK    CMYK
+	HasCMYK bool
+}
+
+// CMYK 各分量為 0-100 的百分比
+type CMYK struct {
+	C, M, Y, K uint8
+}
+
+// NewCMYK 建立 CMYK 顏色，並換算近似的 RGB
+func NewCMYK(c, m, y, k uint8) Color {
+	f := func(v uint8) uint8 {
+		return uint8(math.Round(255 * (1 - float64(v)/100) * (1 - float64(k)/100)))
+	}
+	return Color{R: f(c), G: f(m), B: f(y), A: 255, CMYK: CMYK{C: c, M: m, Y: y, K: k}, HasCMYK: true}
+}
+
+// Opacity 不透明度 0-1
+func (c Color) Opacity() float64 {
+	if c.A == 0 && !c.HasAlpha {
+		return 1
+	}
+	return float64(c.A) / 255
+}
+
+// WithAlpha 回傳指定不透明度 (0-1) 的顏色
+func (c Color) WithAlpha(opacity float64) Color {
+	c.A = uint8(math.Round(math.Max(0, math.Min(opacity, 1)) * 255))
+	c.HasAlpha = true
+	return c
 }
 
 var (
@@ -33,6 +74,21 @@ var (
 		G: 185,
 		B: 255,
 	}
+	ColorHeatWarn = Color{
+		R: 255,
+		G: 224,
+		B: 178,
+	}
+	ColorCoolWarn = Color{
+		R: 204,
+		G: 222,
+		B: 255,
+	}
+	ColorRateAlert = Color{
+		R: 230,
+		G: 190,
+		B: 255,
+	}
 
 	ColorWhite = Color{
 		R: 255,
@@ -42,3 +98,170 @@ var (
 
 	ColorBlack = ColorTableLine
 )
+
+// ParseHexColor 解析 #RGB、#RRGGBB 或 #RRGGBBAA 格式的顏色，# 可省略
+func ParseHexColor(s string) (Color, error) {
+	h := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(h) == 3 {
+		h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+	}
+	if len(h) == 6 {
+		h += "ff"
+	}
+	if len(h) != 8 {
+		return Color{}, fmt.Errorf("invalid color %q", s)
+	}
+	v, err := strconv.ParseUint(h, 16, 32)
+	if err != nil {
+		return Color{}, fmt.Errorf("invalid color %q", s)
+	}
+	c := Color{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}
+	// 明確寫出 alpha，00 為完全透明
+	c.HasAlpha = len(strings.TrimPrefix(strings.TrimSpace(s), "#")) == 8
+	return c, nil
+}
+
+// ParseColor 解析 #RRGGBB 等十六進位、CSS 顏色名稱、rgb()/rgba() 及 cmyk() 格式
+func ParseColor(s string) (Color, error) {
+	t := strings.ToLower(strings.TrimSpace(s))
+	if v, ok := cssColors[t]; ok {
+		return Color{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, nil
+	}
+	if t == "transparent" {
+		return Color{HasAlpha: true}, nil
+	}
+	name, args, ok := colorFunc(t)
+	if !ok {
+		return ParseHexColor(t)
+	}
+	switch {
+	case (name == "rgb" && len(args) == 3) || (name == "rgba" && len(args) == 4):
+		var rgb [3]uint8
+		for i := range rgb {
+			v, err := strconv.ParseUint(args[i], 10, 8)
+			if err != nil {
+				return Color{}, fmt.Errorf("invalid color %q", s)
+			}
+			rgb[i] = uint8(v)
+		}
+		c := Color{R: rgb[0], G: rgb[1], B: rgb[2], A: 255}
+		if len(args) == 4 {
+			a, err := strconv.ParseFloat(args[3], 64)
+			if err != nil || a < 0 || a > 1 {
+				return Color{}, fmt.Errorf("invalid color %q", s)
+			}
+			c = c.WithAlpha(a)
+		}
+		return c, nil
+	case name == "cmyk" && len(args) == 4:
+		var cmyk [4]uint8
+		for i := range cmyk {
+			v, err := strconv.ParseUint(strings.TrimSuffix(args[i], "%"), 10, 8)
+			if err != nil || v > 100 {
+				return Color{}, fmt.Errorf("invalid color %q", s)
+			}
+			cmyk[i] = uint8(v)
+		}
+		return NewCMYK(cmyk[0], cmyk[1], cmyk[2], cmyk[3]), nil
+	}
+	return Color{}, fmt.Errorf("invalid color %q", s)
+}
+
+func colorFunc(s string) (string, []string, bool) {
+	open := strings.IndexByte(s, '(')
+	if open < 0 || !strings.HasSuffix(s, ")") {
+		return "", nil, false
+	}
+	args := strings.Split(s[open+1:len(s)-1], ",")
+	for i := range args {
+		args[i] = strings.TrimSpace(args[i])
+	}
+	return strings.TrimSpace(s[:open]), args, true
+}
+
+// Hex 輸出 #RRGGBB，半透明時輸出 #RRGGBBAA
+func (c Color) Hex() string {
+	if c.Opacity() >= 1 {
+		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+}
+
+func (c Color) MarshalText() ([]byte, error) {
+	if c.HasCMYK {
+		return []byte(fmt.Sprintf("cmyk(%d%%, %d%%, %d%%, %d%%)", c.CMYK.C, c.CMYK.M, c.CMYK.Y, c.CMYK.K)), nil
+	}
+	return []byte(c.Hex()), nil
+}
+
+func (c *Color) UnmarshalText(text []byte) error {
+	color, err := ParseColor(string(text))
+	if err != nil {
+		return err
+	}
+	*c = color
+	return nil
+}
+
+// Gradient 線性漸層
+type Gradient struct {
+	From, To Color
+	// 由上而下，否則由左而右
+	Vertical bool
+}
+
+// At 取得漸層位置 t (0-1) 的顏色
+func (g *Gradient) At(t float64) Color {
+	lerp := func(a, b uint8) uint8 {
+		return uint8(math.Round(float64(a) + (float64(b)-float64(a))*t))
+	}
+	from, to := g.From, g.To
+	alpha := func(c Color) uint8 {
+		return uint8(math.Round(c.Opacity() * 255))
+	}
+	c := Color{R: lerp(from.R, to.R), G: lerp(from.G, to.G), B: lerp(from.B, to.B), A: lerp(alpha(from), alpha(to)), HasAlpha: true}
+	if from.HasCMYK && to.HasCMYK {
+		c.CMYK = CMYK{
+			C: lerp(from.CMYK.C, to.CMYK.C),
+			M: lerp(from.CMYK.M, to.CMYK.M),
+			Y: lerp(from.CMYK.Y, to.CMYK.Y),
+			K: lerp(from.CMYK.K, to.CMYK.K),
+		}
+		c.HasCMYK = true
+	}
+	return c
+}
+
+// Palette 產生 n 個容易區分的顏色，色相以黃金角分布，明度交替
+func Palette(n int) []Color {
+	colors := make([]Color, n)
+	for i := range colors {
+		h := math.Mod(210+float64(i)*137.508, 360)
+		l := []float64{0.45, 0.6, 0.35}[i%3]
+		colors[i] = hsl(h, 0.65, l)
+	}
+	return colors
+}
+
+func hsl(h, s, l float64) Color {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g = c, x
+	case h < 120:
+		r, g = x, c
+	case h < 180:
+		g, b = c, x
+	case h < 240:
+		g, b = x, c
+	case h < 300:
+		r, b = x, c
+	default:
+		r, b = c, x
+	}
+	f := func(v float64) uint8 { return uint8(math.Round((v + m) * 255)) }
+	return Color{R: f(r), G: f(g), B: f(b), A: 255}
+}
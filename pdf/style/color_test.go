@@ -0,0 +1,55 @@
+package style
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseColor(t *testing.T) {
+	for s, want := range map[string]Color{
+		"#f00":                     {R: 255, A: 255},
+		"1a2b3c":                   {R: 0x1a, G: 0x2b, B: 0x3c, A: 255},
+		"#1a2b3c80":                {R: 0x1a, G: 0x2b, B: 0x3c, A: 0x80, HasAlpha: true},
+		"#1a2b3c00":                {R: 0x1a, G: 0x2b, B: 0x3c, HasAlpha: true},
+		"transparent":              {HasAlpha: true},
+		"SteelBlue":                {R: 0x46, G: 0x82, B: 0xb4, A: 255},
+		"rgba(10, 20, 30, 0.5)":    {R: 10, G: 20, B: 30, A: 128, HasAlpha: true},
+		"cmyk(0%, 100%, 100%, 0%)": NewCMYK(0, 100, 100, 0),
+	} {
+		c, err := ParseColor(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, want, c, s)
+	}
+	for _, s := range []string{"#12", "notacolor", "rgb(1,2)", "cmyk(0,0,0,101)"} {
+		_, err := ParseColor(s)
+		assert.Error(t, err, s)
+	}
+
+	c, _ := ParseColor("cmyk(0,100,100,0)")
+	assert.Equal(t, Color{R: 255, A: 255, CMYK: CMYK{M: 100, Y: 100}, HasCMYK: true}, c)
+	assert.True(t, c == NewCMYK(0, 100, 100, 0))
+	text, _ := c.MarshalText()
+	assert.Equal(t, "cmyk(0%, 100%, 100%, 0%)", string(text))
+	assert.Equal(t, 1.0, ColorWhite.Opacity())
+
+	// 完全透明與未設定不同
+	c, _ = ParseColor("#1a2b3c00")
+	assert.Equal(t, 0.0, c.Opacity())
+	assert.Equal(t, "#1a2b3c00", c.Hex())
+	assert.Equal(t, 0.0, ColorWhite.WithAlpha(0).Opacity())
+	c, _ = ParseColor("transparent")
+	assert.Equal(t, 0.0, c.Opacity())
+	assert.NotEqual(t, Color{}, c)
+}
+
+func Test_Palette(t *testing.T) {
+	colors := Palette(12)
+	assert.Len(t, colors, 12)
+	seen := map[Color]bool{}
+	for _, c := range colors {
+		assert.False(t, seen[c])
+		seen[c] = true
+		assert.Equal(t, uint8(255), c.A)
+	}
+}
@@ -0,0 +1,40 @@
+package style
+
+// EventTableStyle 事件表格，Columns 依序為各欄樣式
+type EventTableStyle struct {
+	Columns          []TextBlockStyle
+	HeaderBackground Color
+	// 超出上限或下限事件的欄位底色，未設定時使用欄位樣式
+	HeatAlertBackground Color
+	CoolAlertBackground Color
+	MaxRowCount         int
+}
+
+func eventColumn(w float64) TextBlockStyle {
+	return TextBlockStyle{
+		TextStyle: TextStyle{
+			Font:     "tw-r",
+			FontSize: 8,
+			Color:    ColorBlack,
+		},
+		W:          w,
+		BackGround: ColorWhite,
+	}
+}
+
+// DefaultEventTableStyle 感測器、開始、結束、持續時間、峰值、門檻、確認說明 七欄
+var DefaultEventTableStyle = EventTableStyle{
+	Columns: []TextBlockStyle{
+		eventColumn(70),
+		eventColumn(80),
+		eventColumn(80),
+		eventColumn(50),
+		eventColumn(45),
+		eventColumn(75),
+		eventColumn(155),
+	},
+	HeaderBackground:    ColorGray,
+	HeatAlertBackground: ColorHeatAlert,
+	CoolAlertBackground: ColorCoolAlert,
+	MaxRowCount:         35,
+}
@@ -11,6 +11,7 @@ type SensorV3ReportStyle struct {
 	TableDescMin    TextStyle
 	TableDescAvg    TextStyle
 	StateTableStyle StateTableStyle
+	EventTableStyle EventTableStyle
 	TableStyle      FixRowColumnTableStyle
 	SenColumn	TextStyle
 	SenColumnLine 	Color
@@ -120,6 +121,7 @@ var (
 				BackGround: ColorWhite,
 			},
 		},
+		EventTableStyle: DefaultEventTableStyle,
 		TableStyle: FixRowColumnTableStyle{
 			ChartHeader: TextBlockStyle{
 				TextStyle: TextStyle{
@@ -184,6 +186,33 @@ var (
 				W:          75,
 				BackGround: ColorGray,
 			},
+			HeatWarnContent: TextBlockStyle{
+				TextStyle: TextStyle{
+					Font:     "tw-r",
+					FontSize: 8,
+					Color:    ColorBlack,
+				},
+				W:          75,
+				BackGround: ColorHeatWarn,
+			},
+			CoolWarnContent: TextBlockStyle{
+				TextStyle: TextStyle{
+					Font:     "tw-r",
+					FontSize: 8,
+					Color:    ColorBlack,
+				},
+				W:          75,
+				BackGround: ColorCoolWarn,
+			},
+			RateAlertContent: TextBlockStyle{
+				TextStyle: TextStyle{
+					Font:     "tw-r",
+					FontSize: 8,
+					Color:    ColorBlack,
+				},
+				W:          75,
+				BackGround: ColorRateAlert,
+			},
 		},
 	}
 )
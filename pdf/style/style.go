@@ -33,6 +33,41 @@ type FixRowColumnTableStyle struct {
 	HeatAlertContent TextBlockStyle
 	CoolAlertContent TextBlockStyle
 	BlankContent     TextBlockStyle
+	// 各警示等級的樣式，未設定時 HeatWarnContent、RateAlertContent 沿用 HeatAlertContent，
+	// CoolWarnContent 沿用 CoolAlertContent，MissingContent 沿用 BlankContent
+	HeatWarnContent  TextBlockStyle
+	CoolWarnContent  TextBlockStyle
+	RateAlertContent TextBlockStyle
+	MissingContent   TextBlockStyle
+	// 合併表格每列的欄數，0 時依 MergeLines 平均分配，皆為 0 時為 12
+	MergeColumns int
+	// 合併表格每天折成幾組標題/數值列，0 時依讀值數及 MergeColumns 計算
+	MergeLines int
+	// 合併表格的列高，0 為 20
+	RowHeight float64
+	// 狀態圖表格每列最後加上資料可用率欄，寬度同 RowHeader.W
+	ShowAvailability bool
+}
+
+// MergeLayout 取得合併表格每列的欄數、折成的組數及列高，n 為一天的讀值數
+func (ts FixRowColumnTableStyle) MergeLayout(n int) (columns, lines int, rowHeight float64) {
+	columns, lines, rowHeight = ts.MergeColumns, ts.MergeLines, ts.RowHeight
+	if columns <= 0 {
+		columns = 12
+		if lines > 0 {
+			columns = (n + lines - 1) / lines
+		}
+	}
+	if columns <= 0 {
+		columns = 1
+	}
+	if need := (n + columns - 1) / columns; lines < need {
+		lines = need
+	}
+	if rowHeight <= 0 {
+		rowHeight = 20
+	}
+	return
 }
 
 type StateTableStyle struct {
@@ -51,8 +86,42 @@ type TextStyle struct {
 type TextBlockStyle struct {
 	TextStyle
 	BackGround Color
-	W, H       float64
-	TextAlign  string
+	// 漸層背景，設定時取代 BackGround
+	Gradient *Gradient
+	// 框線，未設定時依繪製方式決定是否畫 0.1 的外框
+	Border *Borders
+	// 內距，未設定時靠左對齊的文字左側留 5
+	Padding *Padding
+	// 圓角半徑，框線需四邊相同才會套用圓角
+	Radius    float64
+	W, H      float64
+	TextAlign string
+}
+
+// Border 框線，Width 為 0 時不畫
+type Border struct {
+	Width float64
+	Color Color
+	// 虛線的線段與間隔長度，空白為實線
+	Dash []float64
+}
+
+type Borders struct {
+	Top, Right, Bottom, Left Border
+}
+
+// AllBorders 四邊相同的框線
+func AllBorders(b Border) *Borders {
+	return &Borders{Top: b, Right: b, Bottom: b, Left: b}
+}
+
+type Padding struct {
+	Top, Right, Bottom, Left float64
+}
+
+// AllPadding 四邊相同的內距
+func AllPadding(v float64) *Padding {
+	return &Padding{Top: v, Right: v, Bottom: v, Left: v}
 }
 
 func (tbs *TextBlockStyle) GetAlign() int {
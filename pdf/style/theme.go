@@ -0,0 +1,260 @@
+package style
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 保護 themes，RegisterTheme 可能與 LoadTheme 同時呼叫
+var themesMu sync.RWMutex
+
+// 可被樣式檔以 base 繼承的具名樣式
+var themes = map[string]interface{}{
+	"daily":      DefaultDialySensorStyle,
+	"weekly":     DefaultWeeklySensorStyle,
+	"monthly":    DefaultMonthlySensorStyle,
+	"yearly":     DefaultYearlySensorStyle,
+	"daily-v2":   DefaultDialySensorV2Style,
+	"weekly-v2":  DefaultWeeklySensorV2Style,
+	"monthly-v2": DefaultMonthlySensorV2Style,
+	"yearly-v2":  DefaultYearlySensorV2Style,
+	"v3":         &SensorV3Style,
+}
+
+// RegisterTheme 註冊具名樣式供其他樣式檔繼承，s 須為樣式型別的指標
+func RegisterTheme(name string, s interface{}) {
+	v := reflect.ValueOf(s)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("theme %s: %T is not a pointer to style", name, s))
+	}
+	c := reflect.New(v.Elem().Type())
+	c.Elem().Set(deepCopy(v.Elem()))
+	themesMu.Lock()
+	themes[name] = c.Interface()
+	themesMu.Unlock()
+}
+
+// LoadTheme 讀取 JSON 或 YAML 樣式檔至 v，v 須為樣式型別的指標，例如 *SensorReportStyle。
+// 欄位名稱不分大小寫，顏色以 "#RRGGBB" 字串表示 (YAML 中須加引號)。
+// 設定 base 時以該具名樣式為基礎，只覆寫檔案中有的欄位；fonts 不為空時檢查字型名稱。
+// 檢查錯誤的 FieldError.Path 使用樣式檔中的鍵名，例如 tableStyle.content.h。
+func LoadTheme(data []byte, v interface{}, fonts ...string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("theme: %T is not a pointer to style", v)
+	}
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("theme: %w", err)
+	}
+	if base, ok := doc["base"]; ok {
+		name, _ := base.(string)
+		themesMu.RLock()
+		b, ok := themes[name]
+		if !ok {
+			themesMu.RUnlock()
+			return fmt.Errorf("theme: unknown base theme %v", base)
+		}
+		bv := reflect.ValueOf(b).Elem()
+		if bv.Type() != rv.Elem().Type() {
+			themesMu.RUnlock()
+			return fmt.Errorf("theme: base theme %s is %s, not %s", name, bv.Type(), rv.Elem().Type())
+		}
+		rv.Elem().Set(deepCopy(bv))
+		themesMu.RUnlock()
+		delete(doc, "base")
+	}
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("theme: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(v); err != nil {
+		return fmt.Errorf("theme: %w", err)
+	}
+
+	var vd validator
+	if len(fonts) > 0 {
+		fontSet := make(map[string]bool, len(fonts))
+		for _, f := range fonts {
+			fontSet[f] = true
+		}
+		vd.hasFont = func(name string) bool { return fontSet[name] }
+	}
+	vd.walk(rv.Elem(), "")
+	for i := range vd.errs {
+		vd.errs[i].Path = yamlPath(doc, vd.errs[i].Path)
+	}
+	return vd.err()
+}
+
+// yamlPath 將欄位路徑轉為樣式檔中的鍵名，檔案中沒有的欄位以小寫開頭的欄位名稱表示
+func yamlPath(doc map[string]interface{}, path string) string {
+	if path == "" {
+		return path
+	}
+	var cur interface{} = doc
+	segs := strings.Split(path, ".")
+	for i, seg := range segs {
+		name, index, _ := strings.Cut(seg, "[")
+		key := strings.ToLower(name[:1]) + name[1:]
+		m, _ := cur.(map[string]interface{})
+		cur = nil
+		// 與 encoding/json 相同，鍵名不分大小寫
+		for k, v := range m {
+			if strings.EqualFold(k, name) {
+				key, cur = k, v
+				break
+			}
+		}
+		if index != "" {
+			for _, s := range strings.Split(strings.TrimSuffix(index, "]"), "][") {
+				n, err := strconv.Atoi(s)
+				list, _ := cur.([]interface{})
+				cur = nil
+				if err == nil && n < len(list) {
+					cur = list[n]
+				}
+			}
+			index = "[" + index
+		}
+		segs[i] = key + index
+	}
+	return strings.Join(segs, ".")
+}
+
+// deepCopy 複製樣式，slice 不與來源共用
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Elem().Type())
+		c.Elem().Set(deepCopy(v.Elem()))
+		return c
+	}
+	return v
+}
+
+// FieldError 樣式欄位錯誤，Path 如 TableStyle.Data[1].W，由 LoadTheme 回傳時為樣式檔鍵名如 tableStyle.data[1].w
+type FieldError struct {
+	Path    string
+	Message string
+}
+
+// ValidationError 所有檢查不通過的欄位
+type ValidationError []FieldError
+
+func (e ValidationError) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Path + ": " + fe.Message
+	}
+	return "invalid style: " + strings.Join(msgs, "; ")
+}
+
+type validator struct {
+	hasFont func(string) bool
+	// 可用的頁面寬度，0 表示不檢查
+	width float64
+	errs  ValidationError
+}
+
+func (vd *validator) add(path, format string, a ...interface{}) {
+	vd.errs = append(vd.errs, FieldError{Path: path, Message: fmt.Sprintf(format, a...)})
+}
+
+func (vd *validator) err() error {
+	if len(vd.errs) == 0 {
+		return nil
+	}
+	return vd.errs
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func (vd *validator) walk(v reflect.Value, path string) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			p := joinPath(path, f.Name)
+			// 內嵌欄位在樣式檔中是攤平的
+			if f.Anonymous {
+				p = path
+			}
+			vd.field(f.Name, v.Field(i), p)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			vd.walk(v.Index(i), fmt.Sprintf("%s[%d]", path, i))
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			vd.walk(v.Elem(), path)
+		}
+	}
+}
+
+func (vd *validator) field(name string, v reflect.Value, path string) {
+	switch {
+	case name == "Font" && v.Kind() == reflect.String:
+		if font := v.String(); font != "" && vd.hasFont != nil && !vd.hasFont(font) {
+			vd.add(path, "unknown font %q", font)
+		}
+	case name == "TextAlign" && v.Kind() == reflect.String:
+		if align := v.String(); align != "" {
+			if _, ok := alignMap[align]; !ok {
+				vd.add(path, "unknown alignment %q", align)
+			}
+		}
+	case v.Kind() == reflect.Float64:
+		if v.Float() < 0 {
+			vd.add(path, "must not be negative, got %v", v.Float())
+		} else if name == "W" && vd.width > 0 && v.Float() > vd.width {
+			vd.add(path, "width %v exceeds page width %v", v.Float(), vd.width)
+		}
+	case v.Kind() == reflect.Int:
+		if v.Int() < 0 {
+			vd.add(path, "must not be negative, got %d", v.Int())
+		}
+	default:
+		vd.walk(v, path)
+	}
+}
@@ -0,0 +1,80 @@
+package style
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoadTheme(t *testing.T) {
+	yml := []byte(`
+base: weekly
+title:
+  font: tw-m
+  fontSize: 20
+  color: "#1a2b3c"
+tableStyle:
+  columnWidth: 120
+`)
+	var s SensorReportStyle
+	assert.NoError(t, LoadTheme(yml, &s, "tw-m", "tw-r"))
+	assert.Equal(t, 20, s.Title.FontSize)
+	assert.Equal(t, Color{R: 0x1a, G: 0x2b, B: 0x3c, A: 255}, s.Title.Color)
+	assert.Equal(t, 120.0, s.TableStyle.ColumnWidth)
+	assert.Equal(t, DefaultWeeklySensorStyle.TableStyle.RowColumnNumber, s.TableStyle.RowColumnNumber)
+	assert.Equal(t, "right", s.SectionBlock.TextAlign)
+
+	s.TableStyle.Data[0].W = 99
+	assert.Equal(t, 30.0, DefaultWeeklySensorStyle.TableStyle.Data[0].W)
+}
+
+func Test_LoadThemeInvalid(t *testing.T) {
+	js := []byte(`{
+		"base": "v3",
+		"title": {"font": "tw-x"},
+		"sectionBlock": {"w": -5, "textAlign": "justify"},
+		"tableStyle": {"content": {"h": -1}}
+	}`)
+	var s SensorV3ReportStyle
+	err := LoadTheme(js, &s, "tw-m", "tw-r")
+	var ve ValidationError
+	if assert.ErrorAs(t, err, &ve) {
+		paths := make([]string, len(ve))
+		for i, fe := range ve {
+			paths[i] = fe.Path
+		}
+		assert.ElementsMatch(t, []string{"title.font", "sectionBlock.w", "sectionBlock.textAlign", "tableStyle.content.h"}, paths)
+	}
+
+	// 鍵名照樣式檔的寫法，清單以索引表示
+	var rs SensorReportStyle
+	err = LoadTheme([]byte("base: weekly\nTableStyle:\n  data:\n    - w: 10\n    - w: -1\n"), &rs)
+	if assert.ErrorAs(t, err, &ve) {
+		assert.Equal(t, "TableStyle.data[1].w", ve[0].Path)
+	}
+
+	assert.Error(t, LoadTheme([]byte(`{"base": "daily"}`), &s))
+	assert.Error(t, LoadTheme([]byte(`{"unknown": 1}`), &s))
+	assert.Error(t, LoadTheme([]byte(`{"title": {"color": "#12"}}`), &s))
+}
+
+func Test_RegisterThemeConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			RegisterTheme(fmt.Sprintf("concurrent-%d", i), DefaultWeeklySensorStyle)
+		}(i)
+		go func() {
+			defer wg.Done()
+			var s SensorReportStyle
+			assert.NoError(t, LoadTheme([]byte("base: weekly"), &s))
+		}()
+	}
+	wg.Wait()
+	var s SensorReportStyle
+	assert.NoError(t, LoadTheme([]byte("base: concurrent-3"), &s))
+}
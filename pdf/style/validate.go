@@ -0,0 +1,116 @@
+package style
+
+import "reflect"
+
+// PDF 檢查樣式所需的文件資訊，pdf.NewPDFv2 回傳的 PDF 即符合此介面
+type PDF interface {
+	GetWidth() float64
+	HasFont(name string) bool
+}
+
+func newValidator(pdf PDF) *validator {
+	return &validator{hasFont: pdf.HasFont, width: pdf.GetWidth()}
+}
+
+// Validate 檢查字型是否已載入、寬度是否超出頁面，回傳所有錯誤
+func (s *SensorReportStyle) Validate(pdf PDF) error {
+	vd := newValidator(pdf)
+	vd.walk(reflect.ValueOf(s).Elem(), "")
+	vd.table(&s.TableStyle, "TableStyle")
+	return vd.err()
+}
+
+func (s *SensorV3ReportStyle) Validate(pdf PDF) error {
+	vd := newValidator(pdf)
+	vd.walk(reflect.ValueOf(s).Elem(), "")
+	vd.fixTable(&s.TableStyle, "TableStyle")
+	vd.stateTable(&s.StateTableStyle, "StateTableStyle")
+	if len(s.EventTableStyle.Columns) > 0 {
+		vd.eventTable(&s.EventTableStyle, "EventTableStyle")
+	}
+	return vd.err()
+}
+
+func (ts *TableStyle) Validate(pdf PDF) error {
+	vd := newValidator(pdf)
+	vd.walk(reflect.ValueOf(ts).Elem(), "")
+	vd.table(ts, "")
+	return vd.err()
+}
+
+func (ts *FixRowColumnTableStyle) Validate(pdf PDF) error {
+	vd := newValidator(pdf)
+	vd.walk(reflect.ValueOf(ts).Elem(), "")
+	vd.fixTable(ts, "")
+	return vd.err()
+}
+
+func (ts *StateTableStyle) Validate(pdf PDF) error {
+	vd := newValidator(pdf)
+	vd.walk(reflect.ValueOf(ts).Elem(), "")
+	vd.stateTable(ts, "")
+	return vd.err()
+}
+
+func (ts *EventTableStyle) Validate(pdf PDF) error {
+	vd := newValidator(pdf)
+	vd.walk(reflect.ValueOf(ts).Elem(), "")
+	vd.eventTable(ts, "")
+	return vd.err()
+}
+
+// table 依 TimeValueTable 每列 RowColumnNumber 欄檢查寬度
+func (vd *validator) table(ts *TableStyle, path string) {
+	if ts.RowColumnNumber <= 0 {
+		vd.add(joinPath(path, "RowColumnNumber"), "must be positive, got %d", ts.RowColumnNumber)
+	} else if w := ts.ColumnWidth * float64(ts.RowColumnNumber); w > vd.width {
+		vd.add(joinPath(path, "ColumnWidth"), "%d columns of %v exceed page width %v", ts.RowColumnNumber, ts.ColumnWidth, vd.width)
+	}
+	if len(ts.Data) == 0 {
+		vd.add(joinPath(path, "Data"), "must not be empty")
+	}
+	var dataW float64
+	for _, d := range ts.Data {
+		dataW += d.W
+	}
+	if dataW > ts.ColumnWidth {
+		vd.add(joinPath(path, "Data"), "width %v exceeds column width %v", dataW, ts.ColumnWidth)
+	}
+}
+
+// fixTable 檢查合併表格一列的寬度，欄數依 MergeLayout 以每小時一筆估計，含可用率欄
+func (vd *validator) fixTable(ts *FixRowColumnTableStyle, path string) {
+	columns, _, _ := ts.MergeLayout(24)
+	n := columns
+	if ts.ShowAvailability {
+		n++
+	}
+	if w := ts.ColumnHeader.W + ts.RowHeader.W*float64(n); w > vd.width {
+		vd.add(joinPath(path, "RowHeader.W"), "%d columns of %v exceed page width %v", n, ts.RowHeader.W, vd.width)
+	}
+}
+
+func (vd *validator) stateTable(ts *StateTableStyle, path string) {
+	if ts.MaxRowCount <= 0 {
+		vd.add(joinPath(path, "MaxRowCount"), "must be positive, got %d", ts.MaxRowCount)
+	}
+	if w := ts.ColumnTime.W + ts.ColumnState.W; w > vd.width {
+		vd.add(path, "time and state columns width %v exceed page width %v", w, vd.width)
+	}
+}
+
+func (vd *validator) eventTable(ts *EventTableStyle, path string) {
+	if ts.MaxRowCount <= 0 {
+		vd.add(joinPath(path, "MaxRowCount"), "must be positive, got %d", ts.MaxRowCount)
+	}
+	if len(ts.Columns) == 0 {
+		vd.add(joinPath(path, "Columns"), "must not be empty")
+	}
+	var w float64
+	for _, c := range ts.Columns {
+		w += c.W
+	}
+	if w > vd.width {
+		vd.add(joinPath(path, "Columns"), "width %v exceeds page width %v", w, vd.width)
+	}
+}
@@ -0,0 +1,73 @@
+package style
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testPDF struct {
+	width float64
+	fonts []string
+}
+
+func (p testPDF) GetWidth() float64 {
+	return p.width
+}
+
+func (p testPDF) HasFont(name string) bool {
+	for _, f := range p.fonts {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+func Test_Validate(t *testing.T) {
+	a4 := testPDF{width: 555.28, fonts: []string{"tw-m", "tw-r"}}
+	assert.NoError(t, DefaultDialySensorStyle.Validate(a4))
+	assert.NoError(t, SensorV3Style.Validate(a4))
+
+	err := DefaultDialySensorStyle.Validate(testPDF{width: 400, fonts: []string{"tw-r"}})
+	var ve ValidationError
+	if assert.ErrorAs(t, err, &ve) {
+		paths := map[string]bool{}
+		for _, fe := range ve {
+			paths[fe.Path] = true
+		}
+		assert.True(t, paths["Title.Font"])
+		assert.True(t, paths["SectionBlock.Font"])
+		assert.True(t, paths["TableStyle.ColumnWidth"])
+		assert.False(t, paths["Content.Font"])
+	}
+
+	ts := StateTableStyle{ColumnTime: TextBlockStyle{W: 300}, ColumnState: TextBlockStyle{W: 300}}
+	assert.ErrorContains(t, ts.Validate(a4), "MaxRowCount: must be positive")
+
+	// 預設 12 欄也須檢查，可用率欄多佔一欄
+	fix := SensorV3Style.TableStyle
+	fix.ShowAvailability = true
+	assert.ErrorContains(t, fix.Validate(a4), "RowHeader.W: 13 columns of 40 exceed page width")
+	fix = SensorV3Style.TableStyle
+	fix.RowHeader.W = 45
+	assert.ErrorContains(t, fix.Validate(a4), "RowHeader.W: 12 columns of 45 exceed page width")
+	fix.MergeLines, fix.RowHeader.W = 1, 21
+	assert.ErrorContains(t, fix.Validate(a4), "RowHeader.W: 24 columns of 21 exceed page width")
+}
+
+func Test_MergeLayout(t *testing.T) {
+	var ts FixRowColumnTableStyle
+	columns, lines, rowH := ts.MergeLayout(24)
+	assert.Equal(t, []interface{}{12, 2, 20.0}, []interface{}{columns, lines, rowH})
+
+	ts.MergeLines = 4
+	columns, lines, _ = ts.MergeLayout(144)
+	assert.Equal(t, []int{36, 4}, []int{columns, lines})
+
+	ts = FixRowColumnTableStyle{MergeColumns: 12, RowHeight: 15}
+	columns, lines, rowH = ts.MergeLayout(48)
+	assert.Equal(t, []interface{}{12, 4, 15.0}, []interface{}{columns, lines, rowH})
+	columns, lines, _ = ts.MergeLayout(12)
+	assert.Equal(t, []int{12, 1}, []int{columns, lines})
+}
@@ -107,6 +107,36 @@ func (p *pdf) TimeValueTable(data []*TableValueAryIter, ts style.TableStyle) []*
 	return nil
 }
 
+// TimeValueTableDrawer 可繪製 style.TableStyle 的時間數值表格
+type TimeValueTableDrawer interface {
+	DrawTimeValueTable(data []*TableValueAryIter, ts style.TableStyle) []*TableValueAryIter
+}
+
+// DrawTimeValueTable 時間數值表格，每個 TableValueAryIter 為一欄，畫滿 PageRowNumber 列時回傳未畫的資料
+func DrawTimeValueTable(p PDF, data []*TableValueAryIter, ts style.TableStyle) ([]*TableValueAryIter, error) {
+	d, err := extension[TimeValueTableDrawer](p, "time value table")
+	if err != nil {
+		return nil, err
+	}
+	return d.DrawTimeValueTable(data, ts), nil
+}
+
+// DrawTimeValueTable 與 v1 的 TimeValueTable 相同
+func (p *pdfv2) DrawTimeValueTable(data []*TableValueAryIter, ts style.TableStyle) []*TableValueAryIter {
+	v1 := &pdf{
+		myPDF:        p.GoPdf,
+		width:        p.width,
+		height:       p.height,
+		leftMargin:   p.leftMargin,
+		topMargin:    p.topMargin,
+		rightMargin:  p.rightMargin,
+		bottomMargin: p.bottomMargin,
+		page:         p.page,
+		fonts:        p.fonts,
+	}
+	return v1.TimeValueTable(data, ts)
+}
+
 func (p *pdf) DrawColumn(w, h float64, color style.Color, rectType string) {
 	pdf := p.myPDF
 	pdf.SetFillColor(color.R, color.G, color.B)
@@ -280,6 +310,39 @@ func (p *pdf) TimeValueTableV2(data []*TableValueAryIter, ts style.TableStyle) [
 	return nil
 }
 
+// Severity 警示等級
+type Severity int8
+
+const (
+	SeverityNormal Severity = iota
+	SeverityHighWarn
+	SeverityHighAlarm
+	SeverityLowWarn
+	SeverityLowAlarm
+	// 變化速率超過上限
+	SeverityRate
+	// 沒有讀值
+	SeverityMissing
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityHighWarn:
+		return "high warn"
+	case SeverityHighAlarm:
+		return "high alarm"
+	case SeverityLowWarn:
+		return "low warn"
+	case SeverityLowAlarm:
+		return "low alarm"
+	case SeverityRate:
+		return "rate of change"
+	case SeverityMissing:
+		return "missing"
+	}
+	return "normal"
+}
+
 type SensorCell struct {
 	Value   string
 	IsAlert int8
@@ -287,6 +350,21 @@ type SensorCell struct {
 	// if overlow, then -1;
 	// if normal, then 0;
 	IsHeader bool
+	// 未設定時依 IsAlert 判斷
+	Severity Severity
+}
+
+func (c SensorCell) severity() Severity {
+	if c.Severity != SeverityNormal {
+		return c.Severity
+	}
+	switch c.IsAlert {
+	case 1:
+		return SeverityHighAlarm
+	case -1:
+		return SeverityLowAlarm
+	}
+	return SeverityNormal
 }
 
 type sensorTableIter struct {
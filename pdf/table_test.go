@@ -0,0 +1,74 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/94peter/export/pdf/style"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DrawSensorMergeTable(t *testing.T) {
+	ts := style.SensorV3Style.TableStyle
+	ts.RowHeader.W = 30
+	ts.MergeColumns = 24
+	ts.RowHeight = 12
+
+	header := make([]string, 144)
+	for i := range header {
+		header[i] = fmt.Sprintf("%02d:%02d", i/6, i%6*10)
+	}
+	nti := GetSensorTableIter(header)
+	for d := 1; d <= 4; d++ {
+		row := []SensorCell{{Value: fmt.Sprintf("01/%02d", d), IsHeader: true}}
+		for i := 0; i < 144; i++ {
+			row = append(row, SensorCell{Value: "5.0"})
+		}
+		nti.AddRow(row)
+	}
+
+	p := NewPDFv2(testFontMap(t), 20, 20, 20, 20)
+	p.AddHorizontalPage()
+	p.DrawSensorMergeTable(nti, 3, 0, ts)
+	assert.Equal(t, uint8(2), p.GetPage())
+	assert.Greater(t, p.GetWidth(), p.GetHeight())
+	// 第二頁只有一天，6 組標題/數值列
+	assert.InDelta(t, 20+6*2*12.0, p.GetY(), 0.01)
+
+	var buf bytes.Buffer
+	assert.NoError(t, p.Write(&buf))
+}
+
+func Test_DrawSenStateChartTable(t *testing.T) {
+	header := []string{"", "0分", "10分", "20分", "30分", "40分", "50分"}
+	nti := GetSensorTableIter(header)
+	row := []SensorCell{{Value: "08時", IsHeader: true}}
+	for i := 0; i < 30; i++ {
+		c := SensorCell{Value: "5.0"}
+		if i%5 == 0 {
+			c = SensorCell{Value: "-", Severity: SeverityMissing}
+		}
+		row = append(row, c)
+	}
+	nti.AddRow(row)
+	assert.InDelta(t, 0.8, availability(row[1:]), 1e-9)
+	assert.Equal(t, 0.0, availability(nil))
+
+	draw := func(ts style.FixRowColumnTableStyle) string {
+		p := NewPDFv2(testFontMap(t), 20, 20, 20, 20).(*pdfv2)
+		p.SetNoCompression()
+		p.AddDirectPage()
+		p.DrawSenStateChartTable(nti, ts)
+		var buf bytes.Buffer
+		assert.NoError(t, p.Write(&buf))
+		return buf.String()
+	}
+	// 可用率欄接在「60分」標題之後，預設不畫
+	ts := style.SensorV3Style.TableStyle
+	assert.NotContains(t, draw(ts), "363.00 799.89 40.00 20.00 re f")
+	ts.ShowAvailability = true
+	out := draw(ts)
+	assert.Contains(t, out, "363.00 799.89 40.00 20.00 re f")
+	assert.Contains(t, out, "363.00 765.89 40.00 20.00 re f")
+}
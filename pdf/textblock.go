@@ -0,0 +1,173 @@
+package pdf
+
+import (
+	"math"
+
+	"github.com/94peter/export/pdf/style"
+	"github.com/signintech/gopdf"
+)
+
+// textBlock 於目前位置依 ts 繪製背景、文字及框線，完成後 X 移至區塊右側。
+// fill 為是否畫背景；ts.Border 未設定時，defaultBorder 決定是否以目前線條顏色畫 0.1 的外框
+func (p *pdfv2) textBlock(text string, ts style.TextBlockStyle, w, h float64, align, valign int, fill, defaultBorder bool) {
+	x, y := p.GetX(), p.GetY()
+	if x < p.leftMargin {
+		x = p.leftMargin
+	}
+	radius := math.Min(ts.Radius, math.Min(w, h)/2)
+
+	if fill {
+		switch {
+		case ts.Gradient != nil:
+			p.gradient(x, y, w, h, ts.Gradient)
+		case radius > 0:
+			p.setFillColor(ts.BackGround)
+			p.withOpacity(ts.BackGround, func() {
+				p.Rectangle(x, y, x+w, y+h, "F", radius, 8)
+			})
+		default:
+			p.rect(x, y, w, h, ts.BackGround, "F")
+		}
+		p.SetFillColor(0, 0, 0)
+	}
+
+	if text != "" {
+		p.blockText(text, ts, x, y, w, h, align, valign)
+	}
+
+	switch {
+	case ts.Border != nil:
+		p.borders(x, y, w, h, *ts.Border, radius)
+	case defaultBorder:
+		p.SetLineWidth(0.1)
+		p.RectFromUpperLeftWithStyle(x, y, w, h, "D")
+	}
+	p.SetY(y)
+	p.SetX(x + w)
+}
+
+// TextBlockDrawer 可於指定位置繪製文字區塊
+type TextBlockDrawer interface {
+	TextBlockXY(text string, ts style.TextBlockStyle, x, y, w, h float64, align, valign int)
+}
+
+// TextBlockXY 於 (x, y) 繪製文字區塊
+func TextBlockXY(p PDF, text string, ts style.TextBlockStyle, x, y, w, h float64, align, valign int) error {
+	d, err := extension[TextBlockDrawer](p, "text block")
+	if err != nil {
+		return err
+	}
+	d.TextBlockXY(text, ts, x, y, w, h, align, valign)
+	return nil
+}
+
+// TextBlockXY 於 (x, y) 繪製文字區塊，不影響目前位置；BackGround 及 Gradient 皆未設定時不畫背景
+func (p *pdfv2) TextBlockXY(text string, ts style.TextBlockStyle, x, y, w, h float64, align, valign int) {
+	ox, oy := p.GetX(), p.GetY()
+	p.SetX(x)
+	p.SetY(y)
+	p.textBlock(text, ts, w, h, align, valign, ts.BackGround != (style.Color{}) || ts.Gradient != nil, false)
+	p.SetX(ox)
+	p.SetY(oy)
+}
+
+// blockText 在扣除內距的範圍內對齊文字，垂直對齊交由 gopdf CellWithOption 的 Top/Middle/Bottom，
+// 由 gopdf 依字型的 typo ascender/descender 計算基線
+func (p *pdfv2) blockText(text string, ts style.TextBlockStyle, x, y, w, h float64, align, valign int) {
+	var pad style.Padding
+	if ts.Padding != nil {
+		pad = *ts.Padding
+	} else if align != style.AlignCenter && align != style.AlignRight {
+		pad.Left = 5
+	}
+
+	opt := gopdf.CellOption{Float: gopdf.Right}
+	switch align {
+	case style.AlignCenter:
+		opt.Align = gopdf.Center
+	case style.AlignRight:
+		opt.Align = gopdf.Right
+	default:
+		opt.Align = gopdf.Left
+	}
+	switch valign {
+	case style.ValignMiddle:
+		opt.Align |= gopdf.Middle
+	case style.ValignBottom:
+		opt.Align |= gopdf.Bottom
+	default:
+		opt.Align |= gopdf.Top
+	}
+
+	p.SetFont(ts.Font, "", ts.FontSize)
+	p.setTextColor(ts.Color)
+	p.SetX(x + pad.Left)
+	p.SetY(y + pad.Top)
+	rect := &gopdf.Rect{W: w - pad.Left - pad.Right, H: h - pad.Top - pad.Bottom}
+	p.withOpacity(ts.Color, func() {
+		p.CellWithOption(rect, text, opt)
+	})
+}
+
+func sameBorder(a, b style.Border) bool {
+	if a.Width != b.Width || a.Color != b.Color || len(a.Dash) != len(b.Dash) {
+		return false
+	}
+	for i := range a.Dash {
+		if a.Dash[i] != b.Dash[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *pdfv2) setBorderLine(b style.Border) {
+	p.setStrokeColor(b.Color)
+	p.SetLineWidth(b.Width)
+	p.setLineDash(b.Dash)
+}
+
+// setLineDash 設定虛線，dash 為空時為實線
+func (p *pdfv2) setLineDash(dash []float64) {
+	p.lineDash = dash
+	if len(dash) > 0 {
+		p.SetCustomLineType(append([]float64(nil), dash...), 0)
+	} else {
+		p.SetLineType("")
+	}
+}
+
+// borders 繪製各邊框線，四邊相同且有圓角時畫圓角外框，畫完還原原本的線條顏色及虛線
+func (p *pdfv2) borders(x, y, w, h float64, b style.Borders, radius float64) {
+	stroke, dash := p.strokeColor, p.lineDash
+	defer func() {
+		p.setStrokeColor(stroke)
+		p.setLineDash(dash)
+	}()
+	if radius > 0 && b.Top.Width > 0 &&
+		sameBorder(b.Top, b.Right) && sameBorder(b.Top, b.Bottom) && sameBorder(b.Top, b.Left) {
+		p.setBorderLine(b.Top)
+		p.withOpacity(b.Top.Color, func() {
+			p.Rectangle(x, y, x+w, y+h, "D", radius, 8)
+		})
+		return
+	}
+	sides := []struct {
+		border         style.Border
+		x1, y1, x2, y2 float64
+	}{
+		{b.Top, x, y, x + w, y},
+		{b.Right, x + w, y, x + w, y + h},
+		{b.Bottom, x, y + h, x + w, y + h},
+		{b.Left, x, y, x, y + h},
+	}
+	for _, s := range sides {
+		if s.border.Width <= 0 {
+			continue
+		}
+		p.setBorderLine(s.border)
+		p.withOpacity(s.border.Color, func() {
+			p.GoPdf.Line(s.x1, s.y1, s.x2, s.y2)
+		})
+	}
+}
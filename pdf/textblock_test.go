@@ -0,0 +1,51 @@
+package pdf
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/94peter/export/pdf/style"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TextBlockBorder(t *testing.T) {
+	p := NewPDFv2(testFontMap(t), 20, 20, 20, 20).(*pdfv2)
+	p.SetNoCompression()
+	p.AddDirectPage()
+	ts := style.TextBlockStyle{
+		TextStyle:  style.TextStyle{Font: "tw-r", FontSize: 10, Color: style.ColorBlack},
+		BackGround: style.ColorGray,
+		Border: &style.Borders{
+			Bottom: style.Border{Width: 1, Color: style.ColorMax, Dash: []float64{2, 1}},
+			Left:   style.Border{Width: 2},
+		},
+		Padding: style.AllPadding(3),
+	}
+	p.RectFillColor("Hello", ts, 100, 30, style.AlignRight, style.ValignBottom)
+	assert.Equal(t, 120.0, p.GetX())
+
+	ts.Border = style.AllBorders(style.Border{Width: 0.5, Color: style.ColorMin})
+	ts.Radius = 4
+	p.Br(40)
+	p.RectFillColor("Round", ts, 100, 30, style.AlignCenter, style.ValignMiddle)
+
+	var buf bytes.Buffer
+	assert.NoError(t, p.Write(&buf))
+	out := buf.String()
+	assert.Contains(t, out, "[2.00 1.00] 0.00 d")
+	assert.Contains(t, out, "20.00 791.89 m 120.00 791.89 l S")
+	assert.Contains(t, out, "2.00 w")
+	assert.NotContains(t, out, "0.10 w")
+
+	// 邊框不影響之前設定的線條顏色
+	p = NewPDFv2(testFontMap(t), 20, 20, 20, 20).(*pdfv2)
+	p.SetNoCompression()
+	p.AddDirectPage()
+	p.LineWithColor(1, style.Color{R: 255})
+	p.RectFillColor("Box", ts, 100, 30, style.AlignCenter, style.ValignMiddle)
+	p.Br(40)
+	p.Line(1)
+	buf.Reset()
+	assert.NoError(t, p.Write(&buf))
+	assert.Regexp(t, `1\.000 0\.000 0\.000 RG\n\[\] 0 d\n1\.00 w\nq\n[\d. ]+ m [\d. ]+ l S\nQ\nendstream`, buf.String())
+}
@@ -0,0 +1,165 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/94peter/export/pdf/style"
+	"github.com/signintech/gopdf"
+)
+
+// Watermark 浮水印，以文字或圖片旋轉後繪製於頁面中央
+type Watermark struct {
+	Text      string
+	TextStyle style.TextStyle
+
+	// 圖片浮水印，有設定時取代文字
+	Image []byte
+	// 圖片寬高，0 則使用原圖尺寸
+	W, H float64
+
+	// 旋轉角度，逆時針
+	Angle float64
+	// 透明度 0~1，0 視為不透明
+	Opacity float64
+	// true 繪於內容之下，false 疊於內容之上
+	Behind bool
+
+	// 套用頁碼範圍(含)，0 表示不限
+	FromPage, ToPage uint8
+}
+
+func (wm *Watermark) inPage(page uint8) bool {
+	if wm.FromPage != 0 && page < wm.FromPage {
+		return false
+	}
+	if wm.ToPage != 0 && page > wm.ToPage {
+		return false
+	}
+	return true
+}
+
+// watermark 已檢查過的浮水印，圖片在加入時解碼
+type watermark struct {
+	Watermark
+	image gopdf.ImageHolder
+	w, h  float64
+}
+
+// Watermarker 可加入浮水印
+type Watermarker interface {
+	AddWatermark(wm Watermark) error
+}
+
+// AddWatermark 加入浮水印，套用於之後完成的每一頁
+func AddWatermark(p PDF, wm Watermark) error {
+	w, err := extension[Watermarker](p, "watermark")
+	if err != nil {
+		return err
+	}
+	return w.AddWatermark(wm)
+}
+
+// AddWatermark 加入浮水印，圖片在此解碼檢查，無法使用時回傳錯誤
+func (p *pdfv2) AddWatermark(wm Watermark) error {
+	if p.pdfa && wm.Opacity > 0 && wm.Opacity < 1 {
+		p.pdfaViolation("watermark opacity %.2f", wm.Opacity)
+		return nil
+	}
+	w := watermark{Watermark: wm}
+	if len(wm.Image) > 0 {
+		data := wm.Image
+		var err error
+		if p.pdfa {
+			if data, err = p.pdfaImage(data); err != nil {
+				return fmt.Errorf("watermark image: %w", err)
+			}
+		}
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("watermark image: %w", err)
+		}
+		// 與繪製時相同的解析，避免於換頁或輸出時才失敗
+		var obj gopdf.ImageObj
+		if err = obj.SetImage(bytes.NewReader(data)); err == nil {
+			err = obj.Parse()
+		}
+		if err != nil {
+			return fmt.Errorf("watermark image: %w", err)
+		}
+		if w.image, err = gopdf.ImageHolderByBytes(data); err != nil {
+			return fmt.Errorf("watermark image: %w", err)
+		}
+		w.w, w.h = wm.W, wm.H
+		if w.w == 0 || w.h == 0 {
+			w.w, w.h = float64(cfg.Width), float64(cfg.Height)
+		}
+	}
+	p.watermarks = append(p.watermarks, w)
+	return nil
+}
+
+// 繪製目前頁面上層浮水印，每頁只會繪製一次
+func (p *pdfv2) finishPage() {
+	if p.page == 0 || p.finishedPage == p.page {
+		return
+	}
+	p.finishedPage = p.page
+	p.drawWatermarks(false, p.width, p.height)
+}
+
+func (p *pdfv2) drawWatermarks(behind bool, pageW, pageH float64) {
+	if len(p.watermarks) == 0 {
+		return
+	}
+	ox, oy := p.GetX(), p.GetY()
+	for i := range p.watermarks {
+		wm := &p.watermarks[i]
+		if wm.Behind != behind || !wm.inPage(p.page) {
+			continue
+		}
+		p.drawWatermark(wm, pageW/2, pageH/2)
+	}
+	p.SetX(ox)
+	p.SetY(oy)
+}
+
+func (p *pdfv2) drawWatermark(wm *watermark, cx, cy float64) {
+	var transparency *gopdf.Transparency
+	if wm.Opacity > 0 && wm.Opacity < 1 {
+		transparency = &gopdf.Transparency{
+			Alpha:         wm.Opacity,
+			BlendModeType: gopdf.NormalBlendMode,
+		}
+	}
+	p.Rotate(wm.Angle, cx, cy)
+	defer p.RotateReset()
+
+	if wm.image != nil {
+		err := p.ImageByHolderWithOptions(wm.image, gopdf.ImageOptions{
+			X:            cx - wm.w/2,
+			Y:            cy - wm.h/2,
+			Rect:         &gopdf.Rect{W: wm.w, H: wm.h},
+			Transparency: transparency,
+		})
+		if err != nil {
+			p.errs = append(p.errs, fmt.Errorf("watermark image: %w", err))
+		}
+		return
+	}
+
+	ts := wm.TextStyle
+	p.SetFont(ts.Font, "", ts.FontSize)
+	p.SetTextColor(ts.Color.R, ts.Color.G, ts.Color.B)
+	textw, _ := p.MeasureTextWidth(wm.Text)
+	p.SetX(cx - textw/2)
+	p.SetY(cy - float64(ts.FontSize)/2)
+	p.CellWithOption(nil, wm.Text, gopdf.CellOption{
+		Align:        gopdf.Left | gopdf.Top,
+		Float:        gopdf.Right,
+		Transparency: transparency,
+	})
+}
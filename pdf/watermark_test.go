@@ -0,0 +1,94 @@
+package pdf
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/94peter/export/pdf/style"
+	"github.com/stretchr/testify/assert"
+)
+
+// pageStreams 依頁面順序取出未壓縮的內容串流
+func pageStreams(out string) []string {
+	var streams []string
+	for _, loc := range regexp.MustCompile(`/Type /Page\s`).FindAllStringIndex(out, -1) {
+		rest := out[loc[1]:]
+		start := strings.Index(rest, "stream\n")
+		end := strings.Index(rest, "endstream")
+		if start < 0 || end < start {
+			continue
+		}
+		streams = append(streams, rest[start:end])
+	}
+	return streams
+}
+
+func Test_Watermark(t *testing.T) {
+	p := NewPDFv2(testFontMap(t), 20, 20, 20, 20).(*pdfv2)
+	p.SetNoCompression()
+	red, green, blue := style.Color{R: 255}, style.Color{G: 255}, style.Color{B: 255}
+	assert.NoError(t, AddWatermark(p, Watermark{
+		Text:      "DRAFT",
+		TextStyle: style.TextStyle{Font: "tw-r", FontSize: 40, Color: red},
+		Angle:     45,
+		Opacity:   0.25,
+		Behind:    true,
+	}))
+	assert.NoError(t, AddWatermark(p, Watermark{
+		Text:      "COPY",
+		TextStyle: style.TextStyle{Font: "tw-r", FontSize: 40, Color: green},
+		FromPage:  2,
+		ToPage:    2,
+	}))
+	body := style.TextStyle{Font: "tw-r", FontSize: 10, Color: blue}
+	for i := 0; i < 3; i++ {
+		p.AddDirectPage()
+		p.Text("body", body, style.AlignLeft)
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, p.Write(&buf))
+	out := buf.String()
+	assert.Contains(t, out, "/ca 0.250")
+
+	pages := pageStreams(out)
+	assert.Len(t, pages, 3)
+	for i, s := range pages {
+		back, text := strings.Index(s, "1.000 0.000 0.000 rg"), strings.Index(s, "0.000 0.000 1.000 rg")
+		over := strings.Index(s, "0.000 1.000 0.000 rg")
+		// 底層浮水印在內文之前，且每頁都有
+		assert.True(t, back >= 0 && back < text, "page %d", i+1)
+		assert.Contains(t, s, "0.70711", "page %d rotated", i+1)
+		if i == 1 {
+			assert.Greater(t, over, text, "page 2 watermark over body")
+		} else {
+			assert.Equal(t, -1, over, "page %d outside range", i+1)
+		}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 8, 4))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	var imgBuf bytes.Buffer
+	assert.NoError(t, png.Encode(&imgBuf, img))
+	p = NewPDFv2(nil, 20, 20, 20, 20).(*pdfv2)
+	p.SetNoCompression()
+	assert.Error(t, AddWatermark(p, Watermark{Image: []byte("not an image")}))
+	assert.NoError(t, AddWatermark(p, Watermark{Image: imgBuf.Bytes(), Opacity: 0.5}))
+	p.AddDirectPage()
+	p.AddDirectPage()
+	buf.Reset()
+	assert.NoError(t, p.Write(&buf))
+	out = buf.String()
+	// 兩頁共用同一個圖片物件
+	assert.Equal(t, 1, strings.Count(out, "/Subtype /Image"))
+	assert.Contains(t, out, "/Width 8")
+	assert.Contains(t, out, "/ca 0.500")
+	for _, s := range pageStreams(out) {
+		assert.Regexp(t, `8\.00 0 0\s+4\.00 [\d.]+ [\d.]+ cm /I\d+ Do`, s)
+	}
+}